@@ -0,0 +1,250 @@
+package goczmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostnameLookupTimeout bounds the DNS lookups made to match
+// a peer address against hostname patterns
+const hostnameLookupTimeout = 2 * time.Second
+
+// resolver is the subset of net.Resolver used to match hostnames
+type resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// addrList is a list of IP addresses, CIDR blocks and hostname
+// patterns, as used by the allow and deny lists of an AuthPolicy.
+type addrList struct {
+	mu       sync.RWMutex
+	prefixes map[netip.Prefix]string
+	hosts    map[string]bool
+}
+
+// newAddrList creates an empty addrList
+func newAddrList() *addrList {
+	return &addrList{
+		prefixes: make(map[netip.Prefix]string),
+		hosts:    make(map[string]bool),
+	}
+}
+
+// parseAddrEntry parses an entry as an IP address, a CIDR block
+// or a hostname pattern, returning its canonical form.
+func parseAddrEntry(entry string) (netip.Prefix, string, error) {
+	if addr, err := netip.ParseAddr(entry); err == nil {
+		addr = addr.Unmap().WithZone("")
+		return netip.PrefixFrom(addr, addr.BitLen()), addr.String(), nil
+	}
+	if prefix, err := netip.ParsePrefix(entry); err == nil {
+		// Peer addresses are unmapped, so an IPv4-mapped block
+		// such as ::ffff:10.0.0.0/104 becomes 10.0.0.0/8
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		prefix = prefix.Masked()
+		if !prefix.IsValid() {
+			return netip.Prefix{}, "", fmt.Errorf("invalid CIDR block %q", entry)
+		}
+		return prefix, prefix.String(), nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(entry, "."))
+	if !validHostPattern(host) {
+		return netip.Prefix{}, "", fmt.Errorf("invalid address, CIDR block or hostname %q", entry)
+	}
+	return netip.Prefix{}, host, nil
+}
+
+// validHostPattern reports whether host is a hostname, optionally
+// starting with a "*." wildcard label
+func validHostPattern(host string) bool {
+	host = strings.TrimPrefix(host, "*.")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// add adds entries, or none of them if any is invalid
+func (l *addrList) add(entries []string) error {
+	type parsed struct {
+		prefix netip.Prefix
+		text   string
+	}
+	all := make([]parsed, 0, len(entries))
+	for _, entry := range entries {
+		prefix, text, err := parseAddrEntry(entry)
+		if err != nil {
+			return err
+		}
+		all = append(all, parsed{prefix, text})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, p := range all {
+		if p.prefix.IsValid() {
+			l.prefixes[p.prefix] = p.text
+		} else {
+			l.hosts[p.text] = true
+		}
+	}
+	return nil
+}
+
+// remove removes entries, ignoring any not in the list
+func (l *addrList) remove(entries []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, entry := range entries {
+		prefix, text, err := parseAddrEntry(entry)
+		if err != nil {
+			continue
+		}
+		if prefix.IsValid() {
+			delete(l.prefixes, prefix)
+		} else {
+			delete(l.hosts, text)
+		}
+	}
+}
+
+// list returns the entries in canonical form, sorted
+func (l *addrList) list() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := make([]string, 0, len(l.prefixes)+len(l.hosts))
+	for _, text := range l.prefixes {
+		entries = append(entries, text)
+	}
+	for host := range l.hosts {
+		entries = append(entries, host)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// match reports whether the peer matches an entry, and whether
+// the list was empty, from a single snapshot of the list.
+// Hostname patterns are only checked, by calling names after the
+// lock is released, if no address or CIDR block matches, and an
+// error from names is returned as is.
+func (l *addrList) match(addr netip.Addr, names func() ([]string, error)) (matched bool, empty bool, err error) {
+	l.mu.RLock()
+	empty = len(l.prefixes) == 0 && len(l.hosts) == 0
+	if addr.IsValid() {
+		for prefix := range l.prefixes {
+			if prefix.Contains(addr) {
+				matched = true
+				break
+			}
+		}
+	}
+	var hosts map[string]bool
+	if !matched && len(l.hosts) > 0 {
+		hosts = maps.Clone(l.hosts)
+	}
+	l.mu.RUnlock()
+
+	if hosts == nil {
+		return matched, empty, nil
+	}
+
+	found, err := names()
+	if err != nil {
+		return false, false, err
+	}
+	for _, name := range found {
+		if hosts[name] {
+			return true, false, nil
+		}
+		for rest := name; strings.Contains(rest, "."); {
+			rest = rest[strings.IndexByte(rest, '.')+1:]
+			if hosts["*."+rest] {
+				return true, false, nil
+			}
+		}
+	}
+	return false, false, nil
+}
+
+// peerAddr parses a ZAP request address
+func peerAddr(address string) netip.Addr {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap().WithZone("")
+}
+
+// lookupNotFound reports whether err only says that a name or
+// address has no DNS records, rather than that the lookup failed
+func lookupNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// confirmedHostnames returns the names of addr found by reverse
+// DNS that also resolve forward to addr, so a peer cannot claim
+// a hostname just by controlling its own reverse zone. Finding
+// no records is not an error, but a lookup that fails or times
+// out is, as the peer's names are then unknown.
+func confirmedHostnames(r resolver, addr netip.Addr) ([]string, error) {
+	if !addr.IsValid() {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hostnameLookupTimeout)
+	defer cancel()
+
+	names, err := r.LookupAddr(ctx, addr.String())
+	if lookupNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var confirmed []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		hosts, err := r.LookupHost(ctx, name)
+		if lookupNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			if peerAddr(host) == addr {
+				confirmed = append(confirmed, name)
+				break
+			}
+		}
+	}
+	return confirmed, nil
+}
@@ -0,0 +1,86 @@
+package goczmq
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nofeaturesonlybugs/z85"
+)
+
+// AuthEvent records the outcome of a ZAP request. It is
+// delivered to the function set with ZapHandler.SetAudit, and
+// can be logged with SlogAudit or encoded as JSON.
+type AuthEvent struct {
+	Time       time.Time `json:"time"`
+	Domain     string    `json:"domain"`
+	Address    string    `json:"address"`
+	Mechanism  string    `json:"mechanism"`
+	Identity   string    `json:"identity,omitempty"`
+	Accepted   bool      `json:"accepted"`
+	StatusCode string    `json:"status_code"`
+	Reason     string    `json:"reason,omitempty"`
+	UserID     string    `json:"user_id,omitempty"`
+}
+
+// newAuthEvent builds the AuthEvent for a request and its reply
+func newAuthEvent(req *ZapRequest, reply [][]byte) AuthEvent {
+	return AuthEvent{
+		Time:       time.Now(),
+		Domain:     req.Domain,
+		Address:    req.Address,
+		Mechanism:  req.Mechanism,
+		Identity:   zapIdentity(req),
+		Accepted:   string(reply[2]) == ZapStatusSuccess,
+		StatusCode: string(reply[2]),
+		Reason:     string(reply[3]),
+		UserID:     string(reply[4]),
+	}
+}
+
+// zapIdentity returns who a request claims to be: the username
+// for PLAIN, the public key text for CURVE and the principal for
+// GSSAPI. PLAIN passwords are never included.
+func zapIdentity(req *ZapRequest) string {
+	if len(req.Credentials) == 0 {
+		return ""
+	}
+
+	switch req.Mechanism {
+	case "PLAIN", "GSSAPI":
+		return string(req.Credentials[0])
+
+	case "CURVE":
+		key, err := z85.Encode(req.Credentials[0])
+		if err != nil {
+			return ""
+		}
+		return key
+	}
+	return ""
+}
+
+// SlogAudit returns an audit function for ZapHandler.SetAudit
+// that logs each AuthEvent to logger, at info level if the client
+// was accepted and at warn level if it was not.
+func SlogAudit(logger *slog.Logger) func(AuthEvent) {
+	return func(e AuthEvent) {
+		level := slog.LevelWarn
+		msg := "zap authentication failed"
+		if e.Accepted {
+			level = slog.LevelInfo
+			msg = "zap authentication succeeded"
+		}
+
+		logger.LogAttrs(context.Background(), level, msg,
+			slog.String("domain", e.Domain),
+			slog.String("address", e.Address),
+			slog.String("mechanism", e.Mechanism),
+			slog.String("identity", e.Identity),
+			slog.Bool("accepted", e.Accepted),
+			slog.String("status_code", e.StatusCode),
+			slog.String("reason", e.Reason),
+			slog.String("user_id", e.UserID),
+		)
+	}
+}
@@ -0,0 +1,91 @@
+package goczmq
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZapHandlerAudit(t *testing.T) {
+	auth := NewPlainAuthenticator()
+	hash, err := hashPassword("Password", 1000)
+	require.NoError(t, err)
+	err = auth.SetHash("admin", hash)
+	require.NoError(t, err)
+
+	handler, err := NewZapHandler(auth)
+	require.NoError(t, err)
+	defer handler.Destroy()
+
+	events := make(chan AuthEvent, 2)
+	handler.SetAudit(func(e AuthEvent) {
+		events <- e
+	})
+
+	req, err := NewReq(ZapEndpoint)
+	require.NoError(t, err)
+	defer req.Destroy()
+
+	for _, password := range []string{"BadPassword", "Password"} {
+		err = req.SendMessage([][]byte{
+			[]byte("1.0"), []byte("1"), []byte("global"), []byte("127.0.0.1"),
+			[]byte(""), []byte("PLAIN"), []byte("admin"), []byte(password),
+		})
+		require.NoError(t, err)
+
+		_, err = req.RecvMessage()
+		require.NoError(t, err)
+	}
+
+	e := <-events
+	assertEqual(t, false, e.Accepted)
+	assertEqual(t, "global", e.Domain)
+	assertEqual(t, "127.0.0.1", e.Address)
+	assertEqual(t, "PLAIN", e.Mechanism)
+	assertEqual(t, "admin", e.Identity)
+	assertEqual(t, ZapStatusFailure, e.StatusCode)
+	assertEqual(t, "Invalid username or password", e.Reason)
+	if e.Time.IsZero() {
+		t.Errorf("expected event time to be set")
+	}
+
+	e = <-events
+	assertEqual(t, true, e.Accepted)
+	assertEqual(t, "admin", e.UserID)
+
+	encoded, err := json.Marshal(e)
+	require.NoError(t, err)
+	if strings.Contains(string(encoded), "Password") {
+		t.Errorf("audit event contains password: %s", encoded)
+	}
+}
+
+func TestSlogAudit(t *testing.T) {
+	var buf bytes.Buffer
+	audit := SlogAudit(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	cert := NewCert()
+	defer cert.Destroy()
+
+	req := curveRequest(t, cert)
+	req.Domain = "admin"
+	req.Address = "10.0.0.1"
+	audit(newAuthEvent(req, [][]byte{
+		[]byte("1.0"), []byte("1"), []byte(ZapStatusFailure), []byte("Certificate revoked"), []byte(""), nil,
+	}))
+
+	var record map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &record)
+	require.NoError(t, err)
+
+	assertEqual(t, "WARN", record["level"])
+	assertEqual(t, "admin", record["domain"])
+	assertEqual(t, "10.0.0.1", record["address"])
+	assertEqual(t, "CURVE", record["mechanism"])
+	assertEqual(t, cert.PublicText(), record["identity"])
+	assertEqual(t, "Certificate revoked", record["reason"])
+}
@@ -0,0 +1,243 @@
+package goczmq
+
+import (
+	"net"
+	"sync"
+
+	"github.com/nofeaturesonlybugs/z85"
+)
+
+// AuthPolicy is an Authenticator that applies the same rules as
+// the Auth actor, but can be scoped to a ZAP domain with a
+// DomainAuthenticator. If any address is allowed, only allowed
+// addresses may connect; otherwise denied addresses may not.
+// NULL clients that pass this check are accepted, while PLAIN and
+// CURVE clients must also pass the policy's authenticator for
+// their mechanism, and are refused if it has none.
+//
+// The allow and deny lists hold IPv4 and IPv6 addresses, CIDR
+// blocks such as "10.1.0.0/16", and hostnames, which may start
+// with a "*." wildcard matching any subdomain. Hostnames are
+// matched by reverse DNS lookup of the peer address, confirmed by
+// a forward lookup, and are only looked up if no address or CIDR
+// block matches. IPv4-mapped IPv6 addresses and blocks, such as
+// "::ffff:10.0.0.0/104", are stored as their IPv4 equivalents.
+//
+// Hostname lookups block the goroutine answering ZAP requests for
+// up to two seconds each, delaying every other handshake, so
+// prefer addresses and CIDR blocks where possible. If a lookup
+// fails or times out, rather than finding no records, the peer is
+// refused whether the hostnames are in the allow or deny list, so
+// a peer cannot slip past a denied hostname by making its own
+// lookups fail.
+type AuthPolicy struct {
+	mu            sync.Mutex
+	allowed       *addrList
+	denied        *addrList
+	resolver      resolver
+	plain         Authenticator
+	curve         Authenticator
+	curveAllowAny bool
+}
+
+// NewAuthPolicy creates an AuthPolicy that accepts NULL clients
+// from any address and refuses PLAIN and CURVE clients.
+func NewAuthPolicy() *AuthPolicy {
+	return &AuthPolicy{
+		allowed:  newAddrList(),
+		denied:   newAddrList(),
+		resolver: net.DefaultResolver,
+	}
+}
+
+// Allow adds addresses, CIDR blocks or hostnames to the policy's
+// allow list. If any entry is invalid, none are added.
+func (p *AuthPolicy) Allow(entries ...string) error {
+	return p.allowed.add(entries)
+}
+
+// Deny adds addresses, CIDR blocks or hostnames to the policy's
+// deny list. If any entry is invalid, none are added.
+func (p *AuthPolicy) Deny(entries ...string) error {
+	return p.denied.add(entries)
+}
+
+// RemoveAllow removes entries from the policy's allow list
+func (p *AuthPolicy) RemoveAllow(entries ...string) {
+	p.allowed.remove(entries)
+}
+
+// RemoveDeny removes entries from the policy's deny list
+func (p *AuthPolicy) RemoveDeny(entries ...string) {
+	p.denied.remove(entries)
+}
+
+// AllowList returns the entries in the policy's allow list,
+// in canonical form and sorted.
+func (p *AuthPolicy) AllowList() []string {
+	return p.allowed.list()
+}
+
+// DenyList returns the entries in the policy's deny list,
+// in canonical form and sorted.
+func (p *AuthPolicy) DenyList() []string {
+	return p.denied.list()
+}
+
+// Plain sets the authenticator for PLAIN clients
+func (p *AuthPolicy) Plain(auth Authenticator) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.plain = auth
+}
+
+// Curve sets the authenticator for CURVE clients, such as
+// a CurveAuthenticator.
+func (p *AuthPolicy) Curve(auth Authenticator) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.curve = auth
+	p.curveAllowAny = false
+}
+
+// CurveAllowAny accepts any CURVE client, as Auth.Curve does
+// when passed CurveAllowAny.
+func (p *AuthPolicy) CurveAllowAny() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.curve = nil
+	p.curveAllowAny = true
+}
+
+// Authenticate satisfies Authenticator
+func (p *AuthPolicy) Authenticate(req *ZapRequest) ZapResponse {
+	addr := peerAddr(req.Address)
+	var names []string
+	var lookupErr error
+	var looked bool
+	hostnames := func() ([]string, error) {
+		if !looked {
+			names, lookupErr = confirmedHostnames(p.resolver, addr)
+			looked = true
+		}
+		return names, lookupErr
+	}
+
+	if allowed, empty, err := p.allowed.match(addr, hostnames); !empty {
+		if err != nil {
+			return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Hostname lookup failed"}
+		}
+		if !allowed {
+			return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Address not allowed"}
+		}
+	} else if denied, _, err := p.denied.match(addr, hostnames); err != nil {
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Hostname lookup failed"}
+	} else if denied {
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Address denied"}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch req.Mechanism {
+	case "NULL":
+		return ZapResponse{StatusCode: ZapStatusSuccess, StatusText: "OK"}
+
+	case "PLAIN":
+		if p.plain != nil {
+			return p.plain.Authenticate(req)
+		}
+
+	case "CURVE":
+		if p.curveAllowAny && len(req.Credentials) == 1 {
+			key, err := z85.Encode(req.Credentials[0])
+			if err != nil {
+				return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Invalid public key"}
+			}
+			return ZapResponse{StatusCode: ZapStatusSuccess, StatusText: "OK", UserID: key}
+		}
+		if p.curve != nil {
+			return p.curve.Authenticate(req)
+		}
+	}
+	return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Mechanism not allowed"}
+}
+
+// DomainAuthenticator is an Authenticator that passes each ZAP
+// request to the authenticator for its domain, as set on the
+// accepting socket with SockSetZapDomain. Requests for a domain
+// with no authenticator go to the default one, if set, and are
+// otherwise refused.
+//
+// When domains are enforced with SetEnforceDomain, requests for a
+// domain with no authenticator of its own are always refused. This
+// matches sockets with the zap_enforce_domain option set, see
+// SockSetZapEnforceDomain, for which libzmq only consults ZAP when
+// a domain is set and fails the handshake when no handler answers.
+type DomainAuthenticator struct {
+	mu            sync.Mutex
+	domains       map[string]Authenticator
+	fallback      Authenticator
+	enforceDomain bool
+}
+
+// NewDomainAuthenticator creates a DomainAuthenticator with no
+// domains, which refuses every request.
+func NewDomainAuthenticator() *DomainAuthenticator {
+	return &DomainAuthenticator{
+		domains: make(map[string]Authenticator),
+	}
+}
+
+// Set sets the authenticator for a domain, such as an AuthPolicy
+func (d *DomainAuthenticator) Set(domain string, auth Authenticator) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.domains[domain] = auth
+}
+
+// Remove removes the authenticator for a domain
+func (d *DomainAuthenticator) Remove(domain string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.domains, domain)
+}
+
+// SetDefault sets the authenticator for domains with none of
+// their own. Passing nil removes it.
+func (d *DomainAuthenticator) SetDefault(auth Authenticator) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.fallback = auth
+}
+
+// SetEnforceDomain sets whether requests for a domain with no
+// authenticator of its own, including the empty domain, are
+// refused rather than passed to the default authenticator.
+func (d *DomainAuthenticator) SetEnforceDomain(enforce bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.enforceDomain = enforce
+}
+
+// Authenticate satisfies Authenticator
+func (d *DomainAuthenticator) Authenticate(req *ZapRequest) ZapResponse {
+	d.mu.Lock()
+	auth, ok := d.domains[req.Domain]
+	if !ok && !d.enforceDomain {
+		auth = d.fallback
+	}
+	d.mu.Unlock()
+
+	if auth == nil {
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Unknown domain"}
+	}
+	return auth.Authenticate(req)
+}
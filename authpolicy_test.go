@@ -0,0 +1,328 @@
+package goczmq
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthPolicy(t *testing.T) {
+	policy := NewAuthPolicy()
+
+	null := &ZapRequest{Address: "127.0.0.1", Mechanism: "NULL"}
+	assertEqual(t, ZapStatusSuccess, policy.Authenticate(null).StatusCode)
+
+	plain := &ZapRequest{Address: "127.0.0.1", Mechanism: "PLAIN", Credentials: [][]byte{[]byte("admin"), []byte("Password")}}
+	assertEqual(t, ZapStatusFailure, policy.Authenticate(plain).StatusCode)
+
+	policy.Plain(AuthenticatorFunc(func(req *ZapRequest) ZapResponse {
+		return ZapResponse{StatusCode: ZapStatusSuccess, UserID: string(req.Credentials[0])}
+	}))
+	resp := policy.Authenticate(plain)
+	assertEqual(t, ZapStatusSuccess, resp.StatusCode)
+	assertEqual(t, "admin", resp.UserID)
+
+	cert := NewCert()
+	defer cert.Destroy()
+	curve := curveRequest(t, cert)
+	curve.Address = "127.0.0.1"
+	assertEqual(t, ZapStatusFailure, policy.Authenticate(curve).StatusCode)
+
+	policy.CurveAllowAny()
+	resp = policy.Authenticate(curve)
+	assertEqual(t, ZapStatusSuccess, resp.StatusCode)
+	assertEqual(t, cert.PublicText(), resp.UserID)
+
+	policy.Deny("127.0.0.1")
+	assertEqual(t, ZapStatusFailure, policy.Authenticate(null).StatusCode)
+
+	policy.Allow("127.0.0.1")
+	assertEqual(t, ZapStatusSuccess, policy.Authenticate(null).StatusCode)
+
+	other := &ZapRequest{Address: "10.0.0.1", Mechanism: "NULL"}
+	assertEqual(t, ZapStatusFailure, policy.Authenticate(other).StatusCode)
+}
+
+type testResolver struct {
+	names map[string][]string
+	hosts map[string][]string
+}
+
+func (r testResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return r.names[addr], nil
+}
+
+func (r testResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return r.hosts[host], nil
+}
+
+func TestAuthPolicyAddressLists(t *testing.T) {
+	policy := NewAuthPolicy()
+	policy.resolver = testResolver{
+		names: map[string][]string{
+			"192.168.1.5": {"worker1.cluster.example.com."},
+			"192.168.1.6": {"worker2.cluster.example.com."},
+		},
+		hosts: map[string][]string{
+			"worker1.cluster.example.com": {"192.168.1.5"},
+			"worker2.cluster.example.com": {"192.168.9.9"},
+		},
+	}
+
+	request := func(address string) string {
+		return policy.Authenticate(&ZapRequest{Address: address, Mechanism: "NULL"}).StatusCode
+	}
+
+	err := policy.Deny("10.1.0.0/16", "2001:db8::/32", "bad.example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.1.0.0/16", "2001:db8::/32", "bad.example.com"}, policy.DenyList())
+
+	assertEqual(t, ZapStatusFailure, request("10.1.200.3"))
+	assertEqual(t, ZapStatusFailure, request("::ffff:10.1.0.1"))
+	assertEqual(t, ZapStatusFailure, request("2001:db8::1"))
+	assertEqual(t, ZapStatusSuccess, request("10.2.0.1"))
+
+	policy.RemoveDeny("10.1.0.0/16")
+	assertEqual(t, ZapStatusSuccess, request("10.1.200.3"))
+
+	err = policy.Allow("127.0.0.1", "10.0.0.0/33")
+	if err == nil {
+		t.Errorf("expected error for invalid CIDR block")
+	}
+	err = policy.Allow("::ffff:10.0.0.0/129")
+	if err == nil {
+		t.Errorf("expected error for invalid CIDR block")
+	}
+	err = policy.Allow("bad host")
+	if err == nil {
+		t.Errorf("expected error for invalid hostname")
+	}
+	require.Equal(t, []string{}, policy.AllowList())
+
+	err = policy.Allow("127.0.0.1", "*.cluster.example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{"*.cluster.example.com", "127.0.0.1"}, policy.AllowList())
+
+	assertEqual(t, ZapStatusSuccess, request("127.0.0.1"))
+	assertEqual(t, ZapStatusSuccess, request("192.168.1.5"))
+	assertEqual(t, ZapStatusFailure, request("192.168.1.6"))
+	assertEqual(t, ZapStatusFailure, request("10.2.0.1"))
+
+	policy.RemoveAllow("127.0.0.1", "*.cluster.example.com")
+	assertEqual(t, ZapStatusSuccess, request("10.2.0.1"))
+}
+
+func TestAuthPolicyMappedCIDR(t *testing.T) {
+	policy := NewAuthPolicy()
+
+	request := func(address string) string {
+		return policy.Authenticate(&ZapRequest{Address: address, Mechanism: "NULL"}).StatusCode
+	}
+
+	err := policy.Deny("::ffff:10.0.0.0/104", "::ffff:192.168.1.5")
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.0/8", "192.168.1.5"}, policy.DenyList())
+
+	assertEqual(t, ZapStatusFailure, request("10.200.0.1"))
+	assertEqual(t, ZapStatusFailure, request("::ffff:10.200.0.1"))
+	assertEqual(t, ZapStatusFailure, request("192.168.1.5"))
+	assertEqual(t, ZapStatusSuccess, request("11.0.0.1"))
+
+	policy.RemoveDeny("10.0.0.0/8")
+	assertEqual(t, ZapStatusSuccess, request("10.200.0.1"))
+}
+
+// failingResolver fails every lookup with err
+type failingResolver struct {
+	err error
+}
+
+func (r failingResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return nil, r.err
+}
+
+func (r failingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return nil, r.err
+}
+
+func TestAuthPolicyLookupFailure(t *testing.T) {
+	policy := NewAuthPolicy()
+	err := policy.Deny("bad.example.com")
+	require.NoError(t, err)
+
+	request := func(address string) string {
+		return policy.Authenticate(&ZapRequest{Address: address, Mechanism: "NULL"}).StatusCode
+	}
+
+	// A peer with no reverse DNS records has no hostname
+	// to deny
+	policy.resolver = failingResolver{err: &net.DNSError{Err: "no such host", IsNotFound: true}}
+	assertEqual(t, ZapStatusSuccess, request("10.2.0.1"))
+
+	// A peer whose lookup fails might be a denied hostname
+	policy.resolver = failingResolver{err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}
+	assertEqual(t, ZapStatusFailure, request("10.2.0.1"))
+
+	policy.RemoveDeny("bad.example.com")
+	assertEqual(t, ZapStatusSuccess, request("10.2.0.1"))
+
+	err = policy.Allow("*.example.com")
+	require.NoError(t, err)
+	assertEqual(t, ZapStatusFailure, request("10.2.0.1"))
+}
+
+// updatingResolver runs update during each reverse lookup
+type updatingResolver struct {
+	testResolver
+	update func()
+}
+
+func (r updatingResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	r.update()
+	return r.testResolver.LookupAddr(ctx, addr)
+}
+
+func TestAuthPolicyLookupUnlocked(t *testing.T) {
+	policy := NewAuthPolicy()
+	err := policy.Allow("*.cluster.example.com")
+	require.NoError(t, err)
+
+	// Lookups run without the list locked, so the list
+	// can be changed while one is in progress
+	policy.resolver = updatingResolver{
+		testResolver: testResolver{
+			names: map[string][]string{"192.168.1.5": {"worker1.cluster.example.com."}},
+			hosts: map[string][]string{"worker1.cluster.example.com": {"192.168.1.5"}},
+		},
+		update: func() {
+			policy.Allow("10.0.0.1")
+		},
+	}
+
+	result := make(chan string)
+	go func() {
+		result <- policy.Authenticate(&ZapRequest{Address: "192.168.1.5", Mechanism: "NULL"}).StatusCode
+	}()
+
+	select {
+	case status := <-result:
+		assertEqual(t, ZapStatusSuccess, status)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+	require.Equal(t, []string{"*.cluster.example.com", "10.0.0.1"}, policy.AllowList())
+}
+
+func TestDomainAuthenticator(t *testing.T) {
+	domains := NewDomainAuthenticator()
+
+	req := &ZapRequest{Domain: "public", Address: "127.0.0.1", Mechanism: "NULL"}
+	assertEqual(t, ZapStatusFailure, domains.Authenticate(req).StatusCode)
+
+	domains.SetDefault(NewAuthPolicy())
+	assertEqual(t, ZapStatusSuccess, domains.Authenticate(req).StatusCode)
+
+	admin := NewAuthPolicy()
+	admin.Deny("127.0.0.1")
+	domains.Set("admin", admin)
+
+	req.Domain = "admin"
+	assertEqual(t, ZapStatusFailure, domains.Authenticate(req).StatusCode)
+
+	domains.Remove("admin")
+	assertEqual(t, ZapStatusSuccess, domains.Authenticate(req).StatusCode)
+
+	domains.SetEnforceDomain(true)
+	assertEqual(t, ZapStatusFailure, domains.Authenticate(req).StatusCode)
+
+	req.Domain = ""
+	assertEqual(t, ZapStatusFailure, domains.Authenticate(req).StatusCode)
+}
+
+func TestDomainAuthenticatorZapHandler(t *testing.T) {
+	store := NewCertStoreInMemory()
+	defer store.Destroy()
+
+	adminKeys := NewCurveAuthenticator(store)
+	admin := NewAuthPolicy()
+	admin.Curve(adminKeys)
+
+	public := NewAuthPolicy()
+	public.CurveAllowAny()
+
+	domains := NewDomainAuthenticator()
+	domains.Set("admin", admin)
+	domains.Set("public", public)
+	domains.SetEnforceDomain(true)
+
+	handler, err := NewZapHandler(domains)
+	require.NoError(t, err)
+	defer handler.Destroy()
+
+	serverCert := NewCert()
+	defer serverCert.Destroy()
+	serverKey := serverCert.PublicText()
+
+	adminServer := NewSock(Pull, SockSetZapDomain("admin"), SockSetZapEnforceDomain(1))
+	defer adminServer.Destroy()
+	serverCert.Apply(adminServer)
+	adminServer.SetOption(SockSetCurveServer(1))
+
+	publicServer := NewSock(Pull, SockSetZapDomain("public"), SockSetZapEnforceDomain(1))
+	defer publicServer.Destroy()
+	serverCert.Apply(publicServer)
+	publicServer.SetOption(SockSetCurveServer(1))
+
+	adminPort, err := adminServer.Bind("tcp://127.0.0.1:*")
+	require.NoError(t, err)
+
+	publicPort, err := publicServer.Bind("tcp://127.0.0.1:*")
+	require.NoError(t, err)
+
+	clientCert := NewCert()
+	defer clientCert.Destroy()
+
+	adminClient := NewSock(Push, SockSetCurveServerkey(serverKey))
+	defer adminClient.Destroy()
+	clientCert.Apply(adminClient)
+
+	publicClient := NewSock(Push, SockSetCurveServerkey(serverKey))
+	defer publicClient.Destroy()
+	clientCert.Apply(publicClient)
+
+	err = adminClient.Connect(fmt.Sprintf("tcp://127.0.0.1:%d", adminPort))
+	require.NoError(t, err)
+
+	err = publicClient.Connect(fmt.Sprintf("tcp://127.0.0.1:%d", publicPort))
+	require.NoError(t, err)
+
+	err = adminClient.SendFrame([]byte("admin"), FlagNone)
+	require.NoError(t, err)
+
+	err = publicClient.SendFrame([]byte("public"), FlagNone)
+	require.NoError(t, err)
+
+	poller, err := NewPoller(adminServer, publicServer)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	s, err := poller.Wait(2000)
+	require.NoError(t, err)
+	if want, have := publicServer, s; want != have {
+		t.Fatalf("want '%#v', have '%#v'", want, have)
+	}
+
+	msg, err := s.RecvMessage()
+	require.NoError(t, err)
+	assertEqual(t, "public", string(msg[0]))
+
+	s, err = poller.Wait(200)
+	require.NoError(t, err)
+	if s != nil {
+		t.Errorf("expected no message from client without an admin key")
+	}
+}
@@ -156,6 +156,14 @@ func (b *Beacon) Recv(timeout int) [][]byte {
 	return [][]byte{addr, beacon}
 }
 
+// Socket returns the actor as a Sock instance, so a beacon
+// can be polled or registered with a Reactor.
+func (b *Beacon) Socket() *Sock {
+	s := &Sock{}
+	s.zsockT = (*C.struct__zsock_t)(unsafe.Pointer(b.zactorT))
+	return s
+}
+
 // Destroy destroys the beacon.
 func (b *Beacon) Destroy() {
 	C.zactor_destroy(&b.zactorT)
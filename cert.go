@@ -82,6 +82,18 @@ func (c *Cert) Meta(key string) string {
 	return C.GoString(val)
 }
 
+// MetaKeys returns the names of a Cert's meta data items
+func (c *Cert) MetaKeys() []string {
+	list := C.zcert_meta_keys(c.zcertT)
+	defer C.zlist_destroy(&list)
+
+	var keys []string
+	for item := C.zlist_first(list); item != nil; item = C.zlist_next(list) {
+		keys = append(keys, C.GoString((*C.char)(item)))
+	}
+	return keys
+}
+
 // PublicText returns the public key as a string
 func (c *Cert) PublicText() string {
 	val := C.zcert_public_txt(c.zcertT)
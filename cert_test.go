@@ -3,6 +3,7 @@ package goczmq
 import (
 	"github.com/stretchr/testify/require"
 	"os"
+	"sort"
 	"testing"
 )
 
@@ -30,6 +31,10 @@ func TestCert(t *testing.T) {
 		t.Errorf("want %#v, have %#v", want, have)
 	}
 
+	keys := cert.MetaKeys()
+	sort.Strings(keys)
+	require.Equal(t, []string{"email", "name", "organization", "version"}, keys)
+
 	_ = cert.PublicText()
 
 	dup := cert.Dup()
@@ -7,8 +7,11 @@ void Sock_init() {zsys_init();}
 import "C"
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"syscall"
 )
 
 // Channeler serializes all access to a socket through a send
@@ -20,45 +23,333 @@ type Channeler struct {
 	id          string
 	sockType    int
 	endpoints   string
-	subscribe   *string
+	subscribe   []string
 	commandAddr string
 	proxyAddr   string
-	commandChan chan<- string
+	commandChan chan<- channelerRequest
+	optionChan  chan SockOption
+	events      bool
+	eventChan   chan ChannelerEvent
 	SendChan    chan<- [][]byte
 	RecvChan    <-chan [][]byte
 	ErrChan     <-chan error
 	errChan     chan<- error
+	done        chan struct{}
+	exited      chan struct{}
 	destroyed   bool
 }
 
+// channelerRequest is a command for a Channeler's actor. It
+// carries the option of a setoption command and the channel
+// its reply is delivered on, so every call owns its reply.
+type channelerRequest struct {
+	cmd    [][]byte
+	option SockOption
+	reply  chan [][]byte
+}
+
+// ChannelerEvent is a connection lifecycle event reported by
+// a Channeler's socket monitor.
+type ChannelerEvent struct {
+	// Type is the zmonitor event name, such as EventConnected
+	Type string
+
+	// Value is the event value, for example a file descriptor
+	// or an error number depending on the event type
+	Value int
+
+	// Endpoint is the endpoint the event applies to
+	Endpoint string
+}
+
+const (
+	// EventConnected is reported when a connection is established
+	EventConnected = "CONNECTED"
+
+	// EventAccepted is reported when a connection to a bound
+	// endpoint is accepted
+	EventAccepted = "ACCEPTED"
+
+	// EventDisconnected is reported when a session is closed
+	EventDisconnected = "DISCONNECTED"
+
+	// EventConnectRetried is reported when a connect is retried
+	EventConnectRetried = "CONNECT_RETRIED"
+
+	// EventHandshakeSucceeded is reported when the security
+	// handshake of a connection succeeds
+	EventHandshakeSucceeded = "HANDSHAKE_SUCCEEDED"
+
+	// EventHandshakeFailedNoDetail is reported when the security
+	// handshake fails for an unspecified reason
+	EventHandshakeFailedNoDetail = "HANDSHAKE_FAILED_NO_DETAIL"
+
+	// EventHandshakeFailedProtocol is reported when the security
+	// handshake fails because of a protocol error
+	EventHandshakeFailedProtocol = "HANDSHAKE_FAILED_PROTOCOL"
+
+	// EventHandshakeFailedAuth is reported when the security
+	// handshake is rejected by authentication
+	EventHandshakeFailedAuth = "HANDSHAKE_FAILED_AUTH"
+)
+
+// channelerEventBuffer is the number of events a Channeler
+// buffers before dropping them
+const channelerEventBuffer = 64
+
+// channelerPipeTimeout is how long in milliseconds the channeler
+// goroutine waits on the command pipe at a time, before checking
+// whether the actor has exited
+const channelerPipeTimeout = 100
+
+// channelerEvents are the events a Channeler monitor listens to
+var channelerEvents = []string{
+	EventConnected,
+	EventAccepted,
+	EventDisconnected,
+	EventConnectRetried,
+	EventHandshakeSucceeded,
+	EventHandshakeFailedNoDetail,
+	EventHandshakeFailedProtocol,
+	EventHandshakeFailedAuth,
+}
+
 // Destroy sends a message to the Channeler to shut it down
 // and clean it up.
 func (c *Channeler) Destroy() {
 	if c.destroyed {
 		return
 	}
-	c.commandChan <- "destroy"
+	select {
+	case c.commandChan <- channelerRequest{cmd: [][]byte{[]byte("destroy")}}:
+	case <-c.done:
+	}
 }
 
-// Subscribe to a Topic
-func (c *Channeler) Subscribe(topic string) {
+// Subscribe to a Topic. The topic is passed to the socket
+// unmodified, so it may contain spaces or binary data.
+func (c *Channeler) Subscribe(topic string) error {
+	return c.command([]byte("subscribe"), []byte(topic))
+}
+
+// Unsubscribe from a Topic
+func (c *Channeler) Unsubscribe(topic string) error {
+	return c.command([]byte("unsubscribe"), []byte(topic))
+}
+
+// Connect connects the Channeler's socket to an additional
+// endpoint. The connect is executed on the socket thread and
+// any error is returned to the caller.
+func (c *Channeler) Connect(endpoint string) error {
+	return c.command([]byte("connect"), []byte(endpoint))
+}
+
+// Disconnect disconnects the Channeler's socket from an endpoint.
+func (c *Channeler) Disconnect(endpoint string) error {
+	return c.command([]byte("disconnect"), []byte(endpoint))
+}
+
+// Bind binds the Channeler's socket to an additional endpoint.
+// On success it returns the port number used for tcp transports,
+// or 0 for other transports.
+func (c *Channeler) Bind(endpoint string) (int, error) {
 	if c.destroyed {
-		return
+		return -1, ErrActorCmd
+	}
+	reply := c.request([][]byte{[]byte("bind"), []byte(endpoint)}, nil)
+	if err := channelerReplyError(reply); err != nil {
+		return -1, err
 	}
-	c.commandChan <- fmt.Sprintf("subscribe %s", topic)
+	return strconv.Atoi(string(reply[1]))
 }
 
-// Unsubscribe from a Topic
-func (c *Channeler) Unsubscribe(topic string) {
+// Unbind unbinds the Channeler's socket from an endpoint.
+func (c *Channeler) Unbind(endpoint string) error {
+	return c.command([]byte("unbind"), []byte(endpoint))
+}
+
+// SetOption sets an option on the Channeler's socket. The
+// option is applied on the socket thread, and SetOption returns
+// once it has been. A SockOption does not report whether ZeroMQ
+// accepted the value, so the only error returned is ErrActorCmd,
+// if the Channeler has stopped.
+func (c *Channeler) SetOption(o SockOption) error {
+	if c.destroyed || o == nil {
+		return ErrActorCmd
+	}
+	return channelerReplyError(c.request([][]byte{[]byte("setoption")}, o))
+}
+
+// Events returns a channel of the connection lifecycle events
+// reported by a Monitor on the Channeler's socket. Monitoring is
+// enabled by setting Events in the ChannelerSock passed to
+// NewChanneler, which starts the monitor before the socket is
+// attached, so events for the constructor's endpoints are
+// reported too. Other Channelers return ErrEventsDisabled. Up to
+// channelerEventBuffer events are buffered, and further events
+// are dropped until the channel is drained. Every call returns
+// the same channel, which is closed when the Channeler is
+// destroyed.
+func (c *Channeler) Events() (<-chan ChannelerEvent, error) {
+	if !c.events {
+		return nil, ErrEventsDisabled
+	}
+	return c.eventChan, nil
+}
+
+// command sends a command to the actor and waits for the result.
+func (c *Channeler) command(cmd ...[]byte) error {
 	if c.destroyed {
-		return
+		return ErrActorCmd
+	}
+	return channelerReplyError(c.request(cmd, nil))
+}
+
+// request sends a command to the actor and returns its reply,
+// or nil if the actor has exited.
+func (c *Channeler) request(cmd [][]byte, option SockOption) [][]byte {
+	return sendChannelerRequest(c.commandChan, c.done, cmd, option)
+}
+
+// sendChannelerRequest sends cmd with its option over commandChan
+// and waits for the reply, returning nil once done is closed.
+func sendChannelerRequest(commandChan chan<- channelerRequest, done <-chan struct{}, cmd [][]byte, option SockOption) [][]byte {
+	req := channelerRequest{cmd: cmd, option: option, reply: make(chan [][]byte, 1)}
+	select {
+	case commandChan <- req:
+	case <-done:
+		return nil
+	}
+
+	select {
+	case reply := <-req.reply:
+		return reply
+	case <-done:
+		return nil
+	}
+}
+
+// forwardChannelerRequest passes req to the actor over pipe and
+// delivers the actor's reply. The option of a setoption command
+// is handed to the actor through options just before the command,
+// so it is never queued once the actor has exited. It reports
+// false once the actor has exited, as the pipe then has no peer.
+func forwardChannelerRequest(pipe *Sock, poller *Poller, options chan<- SockOption, done <-chan struct{}, errChan chan<- error, req channelerRequest) bool {
+	if req.option != nil {
+		select {
+		case options <- req.option:
+		case <-done:
+			return false
+		}
+	}
+
+	err := sendChannelerPipe(pipe, done, req.cmd)
+	if err == nil {
+		var reply [][]byte
+		reply, err = recvChannelerPipe(pipe, poller, done)
+		if err == nil {
+			req.reply <- reply
+			return true
+		}
+	}
+	if err == ErrActorCmd {
+		return false
+	}
+	errChan <- err
+	req.reply <- nil
+	return true
+}
+
+// sendChannelerPipe sends msg to the actor over the channeler's
+// end of the command pipe, which has a send timeout so that it
+// returns ErrActorCmd rather than blocking once done is closed.
+func sendChannelerPipe(pipe *Sock, done <-chan struct{}, msg [][]byte) error {
+	for {
+		select {
+		case <-done:
+			return ErrActorCmd
+		default:
+		}
+
+		err := pipe.SendMessage(msg)
+		if err != syscall.EAGAIN {
+			return err
+		}
 	}
-	c.commandChan <- fmt.Sprintf("unsubscribe %s", topic)
+}
+
+// recvChannelerPipe waits for the actor's reply on the
+// channeler's end of the command pipe, returning ErrActorCmd
+// rather than blocking once done is closed.
+func recvChannelerPipe(pipe *Sock, poller *Poller, done <-chan struct{}) ([][]byte, error) {
+	for {
+		s, err := poller.Wait(channelerPipeTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			return pipe.RecvMessage()
+		}
+
+		select {
+		case <-done:
+			return nil, ErrActorCmd
+		default:
+		}
+	}
+}
+
+// newChannelerPipe creates the channeler goroutine's end of
+// the command pipe, bound to addr, and a Poller for its replies.
+func newChannelerPipe(addr string) (*Sock, *Poller, error) {
+	pipe, err := NewPair(fmt.Sprintf("@%s", addr), SockSetSndtimeo(channelerPipeTimeout))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	poller, err := NewPoller(pipe)
+	if err != nil {
+		pipe.Destroy()
+		return nil, nil, err
+	}
+	return pipe, poller, nil
+}
+
+// channelerReply builds the reply the actor sends back
+// over the command pipe for a command result.
+func channelerReply(err error, values ...[]byte) [][]byte {
+	if err != nil {
+		return [][]byte{[]byte("error"), []byte(err.Error())}
+	}
+	return append([][]byte{[]byte("ok")}, values...)
+}
+
+// channelerReplyError turns a reply from the actor back into
+// an error, restoring the package's sentinel errors.
+func channelerReplyError(reply [][]byte) error {
+	if len(reply) == 0 {
+		return ErrActorCmd
+	}
+	if string(reply[0]) == "ok" {
+		return nil
+	}
+	if len(reply) < 2 {
+		return ErrActorCmd
+	}
+	msg := string(reply[1])
+	for _, e := range []error{ErrActorCmd, ErrInvalidSockType, ErrConnect, ErrDisconnect, ErrBind, ErrUnbind, ErrUnknownSock} {
+		if e.Error() == msg {
+			return e
+		}
+	}
+	return errors.New(msg)
 }
 
 // actor is a routine that handles communication with
 // the zeromq socket.
 func (c *Channeler) actor(recvChan chan<- [][]byte, options []SockOption) {
+	defer close(c.done)
+
 	pipe, err := NewPair(fmt.Sprintf(">%s", c.commandAddr))
 
 	if err != nil {
@@ -67,6 +358,9 @@ func (c *Channeler) actor(recvChan chan<- [][]byte, options []SockOption) {
 	}
 	defer pipe.Destroy()
 	defer close(recvChan)
+	if c.events {
+		defer close(c.eventChan)
+	}
 
 	pull, err := NewPull(c.proxyAddr)
 	if err != nil {
@@ -77,37 +371,22 @@ func (c *Channeler) actor(recvChan chan<- [][]byte, options []SockOption) {
 
 	sock := NewSock(c.sockType, options...)
 	defer sock.Destroy()
-	switch c.sockType {
-	case Pub, Rep, Pull, Router, XPub:
-		err = sock.Attach(c.endpoints, true)
-		if err != nil {
-			c.errChan <- err
-			return
-		}
-
-	case Req, Push, Dealer, Pair, Stream, XSub:
-		err = sock.Attach(c.endpoints, false)
-		if err != nil {
-			c.errChan <- err
-			return
-		}
 
-	case Sub:
-		if c.subscribe != nil {
-			subscriptions := strings.Split(*c.subscribe, ",")
-			for _, topic := range subscriptions {
-				sock.SetOption(SockSetSubscribe(topic))
-			}
-		}
-
-		err = sock.Attach(c.endpoints, false)
+	// The monitor is started before the socket is attached, so
+	// that events for the initial endpoints are reported
+	var monitor *Monitor
+	if c.events {
+		monitor, err = startChannelerMonitor(sock)
 		if err != nil {
 			c.errChan <- err
 			return
 		}
+		defer monitor.Destroy()
+	}
 
-	default:
-		c.errChan <- ErrInvalidSockType
+	err = attachChannelerSock(sock, c.endpoints, c.subscribe)
+	if err != nil {
+		c.errChan <- err
 		return
 	}
 
@@ -118,8 +397,19 @@ func (c *Channeler) actor(recvChan chan<- [][]byte, options []SockOption) {
 	}
 	defer poller.Destroy()
 
+	if monitor != nil {
+		err = poller.Add(monitor.Socket())
+		if err != nil {
+			c.errChan <- err
+			goto ExitActor
+		}
+	}
+
 	for {
 		s, err := poller.Wait(-1)
+		if err == ErrTerminated {
+			goto ExitActor
+		}
 		if err != nil {
 			c.errChan <- err
 			continue
@@ -132,26 +422,19 @@ func (c *Channeler) actor(recvChan chan<- [][]byte, options []SockOption) {
 				goto ExitActor
 			}
 
-			switch string(cmd[0]) {
-			case "destroy":
+			if string(cmd[0]) == "destroy" {
 				disconnect := strings.Split(c.endpoints, ",")
 				for _, endpoint := range disconnect {
 					sock.Disconnect(endpoint)
 				}
-				pipe.SendMessage([][]byte{[]byte("ok")})
+				pipe.SendMessage(channelerReply(nil))
 				goto ExitActor
-			case "subscribe":
-				topic := string(cmd[1])
-				sock.SetOption(SockSetSubscribe(topic))
-				pipe.SendMessage([][]byte{[]byte("ok")})
-			case "unsubscribe":
-				topic := string(cmd[1])
-				sock.SetOption(SockSetUnsubscribe(topic))
-				pipe.SendMessage([][]byte{[]byte("ok")})
 			}
 
+			pipe.SendMessage(execChannelerCommand(sock, cmd, c.optionChan))
+
 		case sock:
-			msg, err := s.RecvMessage()
+			msg, err := recvChannelerMessage(s)
 			if err != nil {
 				c.errChan <- err
 				continue
@@ -165,19 +448,153 @@ func (c *Channeler) actor(recvChan chan<- [][]byte, options []SockOption) {
 				continue
 			}
 
-			err = sock.SendMessage(msg)
+			err = sendChannelerMessage(sock, msg)
 			if err != nil {
 				c.errChan <- err
 				continue
 			}
+
+		default:
+			if monitor == nil || s.zsockT != monitor.Socket().zsockT {
+				continue
+			}
+			msg, err := s.RecvMessage()
+			if err != nil {
+				c.errChan <- err
+				continue
+			}
+			if len(msg) != 3 {
+				continue
+			}
+			value, _ := strconv.Atoi(string(msg[1]))
+			select {
+			case c.eventChan <- ChannelerEvent{
+				Type:     string(msg[0]),
+				Value:    value,
+				Endpoint: string(msg[2]),
+			}:
+			default:
+				// Drop the event rather than stall the socket
+				// when nobody is draining Events
+			}
 		}
 	}
 ExitActor:
 }
 
+// startChannelerMonitor creates and starts a Monitor for the
+// connection lifecycle events reported by a Channeler.
+func startChannelerMonitor(sock *Sock) (*Monitor, error) {
+	monitor := NewMonitor(sock)
+	for _, event := range channelerEvents {
+		err := monitor.Listen(event)
+		if err != nil {
+			monitor.Destroy()
+			return nil, err
+		}
+	}
+
+	err := monitor.Start()
+	if err != nil {
+		monitor.Destroy()
+		return nil, err
+	}
+	return monitor, nil
+}
+
+// attachChannelerSock subscribes sock to any initial topics
+// and attaches it to endpoints, binding or connecting by
+// default depending on the socket type.
+func attachChannelerSock(sock *Sock, endpoints string, subscribe []string) error {
+	switch sock.GetType() {
+	case Pub, Rep, Pull, Router, XPub:
+		return sock.Attach(endpoints, true)
+
+	case Req, Push, Dealer, Pair, Stream, XSub:
+		return sock.Attach(endpoints, false)
+
+	case Sub:
+		for _, topic := range subscribe {
+			err := sock.setSockOptBytes(int(C.ZMQ_SUBSCRIBE), []byte(topic))
+			if err != nil {
+				return err
+			}
+		}
+		return sock.Attach(endpoints, false)
+
+	default:
+		if handled, err := attachDraftSock(sock, endpoints, subscribe); handled {
+			return err
+		}
+		return ErrInvalidSockType
+	}
+}
+
+// recvChannelerMessage receives a message from sock in the
+// form it is delivered on a Channeler's RecvChan.
+func recvChannelerMessage(sock *Sock) ([][]byte, error) {
+	if msg, handled, err := recvDraftMessage(sock); handled {
+		return msg, err
+	}
+	return sock.RecvMessage()
+}
+
+// sendChannelerMessage sends a message taken from a
+// Channeler's SendChan on sock.
+func sendChannelerMessage(sock *Sock, msg [][]byte) error {
+	if handled, err := sendDraftMessage(sock, msg); handled {
+		return err
+	}
+	return sock.SendMessage(msg)
+}
+
+// execChannelerCommand runs a command received over the command
+// pipe against sock and returns the reply to send back. Commands
+// are a verb frame followed by binary argument frames.
+func execChannelerCommand(sock *Sock, cmd [][]byte, options <-chan SockOption) [][]byte {
+	var err error
+	verb, args := string(cmd[0]), cmd[1:]
+
+	switch verb {
+	case "setoption":
+		// SockOptions have no error result, so only
+		// the handoff itself can be reported
+		sock.SetOption(<-options)
+		return channelerReply(nil)
+	case "subscribe", "unsubscribe", "connect", "disconnect", "bind", "unbind":
+		if len(args) != 1 {
+			return channelerReply(ErrActorCmd)
+		}
+	default:
+		if reply, handled := execDraftCommand(sock, verb, args); handled {
+			return reply
+		}
+		return channelerReply(ErrActorCmd)
+	}
+
+	switch verb {
+	case "subscribe":
+		err = sock.setSockOptBytes(int(C.ZMQ_SUBSCRIBE), args[0])
+	case "unsubscribe":
+		err = sock.setSockOptBytes(int(C.ZMQ_UNSUBSCRIBE), args[0])
+	case "connect":
+		err = sock.Connect(string(args[0]))
+	case "disconnect":
+		err = sock.Disconnect(string(args[0]))
+	case "bind":
+		port, err := sock.Bind(string(args[0]))
+		return channelerReply(err, []byte(strconv.Itoa(port)))
+	case "unbind":
+		err = sock.Unbind(string(args[0]))
+	}
+	return channelerReply(err)
+}
+
 // channeler is a routine that handles the channel select loop
 // and sends commands to the zeromq socket.
-func (c *Channeler) channeler(commandChan <-chan string, sendChan <-chan [][]byte) {
+func (c *Channeler) channeler(commandChan <-chan channelerRequest, sendChan <-chan [][]byte) {
+	defer close(c.exited)
+
 	push, err := NewPush(c.proxyAddr)
 	if err != nil {
 		c.errChan <- err
@@ -185,44 +602,31 @@ func (c *Channeler) channeler(commandChan <-chan string, sendChan <-chan [][]byt
 	}
 	defer push.Destroy()
 
-	pipe, err := NewPair(fmt.Sprintf("@%s", c.commandAddr))
+	pipe, poller, err := newChannelerPipe(c.commandAddr)
 	if err != nil {
 		c.errChan <- err
 		goto ExitChanneler
 	}
 	defer pipe.Destroy()
+	defer poller.Destroy()
 
 	for {
 		select {
-		case cmd := <-commandChan:
-			switch cmd {
-			case "destroy":
+		case req := <-commandChan:
+			if string(req.cmd[0]) == "destroy" {
 				c.destroyed = true
-				err = pipe.SendFrame([]byte("destroy"), FlagNone)
-				if err != nil {
-					c.errChan <- err
-				} else {
-					_, err = pipe.RecvMessage()
-					if err != nil {
-						c.errChan <- err
-					}
+				err = sendChannelerPipe(pipe, c.done, req.cmd)
+				if err == nil {
+					_, err = recvChannelerPipe(pipe, poller, c.done)
 				}
-				goto ExitChanneler
-			default:
-				parts := strings.Split(cmd, " ")
-				numParts := len(parts)
-				message := make([][]byte, numParts, numParts)
-				for i, p := range parts {
-					message[i] = []byte(p)
-				}
-				err := pipe.SendMessage(message)
-				if err != nil {
-					c.errChan <- err
-				}
-				_, err = pipe.RecvMessage()
-				if err != nil {
+				if err != nil && err != ErrActorCmd {
 					c.errChan <- err
 				}
+				goto ExitChanneler
+			}
+
+			if !forwardChannelerRequest(pipe, poller, c.optionChan, c.done, c.errChan, req) {
+				goto ExitChanneler
 			}
 
 		case msg := <-sendChan:
@@ -230,6 +634,9 @@ func (c *Channeler) channeler(commandChan <-chan string, sendChan <-chan [][]byt
 			if err != nil {
 				c.errChan <- err
 			}
+
+		case <-c.done:
+			goto ExitChanneler
 		}
 	}
 ExitChanneler:
@@ -238,7 +645,20 @@ ExitChanneler:
 // newChanneler accepts arguments from the socket type based
 // constructors and creates a new Channeler instance
 func newChanneler(sockType int, endpoints string, subscribe []string, options []SockOption) *Channeler {
-	commandChan := make(chan string)
+	return NewChanneler(ChannelerSock{
+		Type:      sockType,
+		Endpoints: endpoints,
+		Subscribe: subscribe,
+		Options:   options,
+	})
+}
+
+// NewChanneler creates a new Channeler for the socket described
+// by spec, which binds or connects by default in the same way as
+// the socket type based constructors. Its Name is not used.
+// Setting Events enables Channeler.Events.
+func NewChanneler(spec ChannelerSock) *Channeler {
+	commandChan := make(chan channelerRequest)
 	sendChan := make(chan [][]byte)
 	recvChan := make(chan [][]byte)
 	errChan := make(chan error)
@@ -246,9 +666,13 @@ func newChanneler(sockType int, endpoints string, subscribe []string, options []
 	C.Sock_init()
 	c := &Channeler{
 		id:          C.GoString(C.zuuid_str(C.zuuid_new())),
-		endpoints:   endpoints,
-		sockType:    sockType,
+		endpoints:   spec.Endpoints,
+		sockType:    spec.Type,
 		commandChan: commandChan,
+		optionChan:  make(chan SockOption, 1),
+		events:      spec.Events,
+		done:        make(chan struct{}),
+		exited:      make(chan struct{}),
 		SendChan:    sendChan,
 		RecvChan:    recvChan,
 		ErrChan:     errChan,
@@ -257,13 +681,13 @@ func newChanneler(sockType int, endpoints string, subscribe []string, options []
 	c.commandAddr = fmt.Sprintf("inproc://actorcontrol_%s", c.id)
 	c.proxyAddr = fmt.Sprintf("inproc://proxy_%s", c.id)
 
-	if len(subscribe) > 0 {
-		topics := strings.Join(subscribe, ",")
-		c.subscribe = &topics
+	c.subscribe = spec.Subscribe
+	if c.events {
+		c.eventChan = make(chan ChannelerEvent, channelerEventBuffer)
 	}
 
 	go c.channeler(commandChan, sendChan)
-	go c.actor(recvChan, options)
+	go c.actor(recvChan, spec.Options)
 
 	return c
 }
@@ -277,8 +701,10 @@ func NewPubChanneler(endpoints string, options ...SockOption) *Channeler {
 // NewSubChanneler creates a new Channeler wrapping
 // a Sub socket. Along with an endpoint list
 // it accepts a list of topics and/or socket options
-// (discriminated by type). The socket will connect
-// by default.
+// (discriminated by type). String topics are split on
+// commas, while a []byte is subscribed to unmodified as a
+// single topic, so it may contain commas or binary data.
+// The socket will connect by default.
 func NewSubChanneler(endpoints string, varargs ...interface{}) *Channeler {
 	subscribe := []string{}
 	options := []SockOption{}
@@ -287,7 +713,9 @@ func NewSubChanneler(endpoints string, varargs ...interface{}) *Channeler {
 	for _, arg := range varargs {
 		switch x := arg.(type) {
 		case string:
-			subscribe = append(subscribe, x)
+			subscribe = append(subscribe, strings.Split(x, ",")...)
+		case []byte:
+			subscribe = append(subscribe, string(x))
 		case SockOption:
 			options = append(options, x)
 		default:
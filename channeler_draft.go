@@ -0,0 +1,214 @@
+//go:build draft
+// +build draft
+
+package goczmq
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Join joins a group on a Dish Channeler
+func (c *Channeler) Join(group string) error {
+	return c.command([]byte("join"), []byte(group))
+}
+
+// Leave leaves a group on a Dish Channeler
+func (c *Channeler) Leave(group string) error {
+	return c.command([]byte("leave"), []byte(group))
+}
+
+// Join joins a group on the named Dish socket
+func (c *MultiChanneler) Join(name string, group string) error {
+	return c.command(name, []byte("join"), []byte(group))
+}
+
+// Leave leaves a group on the named Dish socket
+func (c *MultiChanneler) Leave(name string, group string) error {
+	return c.command(name, []byte("leave"), []byte(group))
+}
+
+// attachDraftSock attaches draft socket types, joining any
+// initial groups for Dish sockets.
+func attachDraftSock(sock *Sock, endpoints string, groups []string) (bool, error) {
+	switch sock.GetType() {
+	case Server, Gather, Radio:
+		return true, sock.Attach(endpoints, true)
+
+	case Client, Scatter:
+		return true, sock.Attach(endpoints, false)
+
+	case Dish:
+		for _, group := range groups {
+			err := sock.Join(group)
+			if err != nil {
+				return true, err
+			}
+		}
+		return true, sock.Attach(endpoints, false)
+
+	default:
+		return false, nil
+	}
+}
+
+// recvDraftMessage receives a message from a draft socket.
+// Server messages are prefixed with the 4 byte big endian
+// routing id of the peer, and Dish messages with their group.
+func recvDraftMessage(sock *Sock) ([][]byte, bool, error) {
+	switch sock.GetType() {
+	case Server:
+		frame, routingID, err := sock.RecvServerFrame()
+		if err != nil {
+			return nil, true, err
+		}
+		id := make([]byte, 4)
+		binary.BigEndian.PutUint32(id, routingID)
+		return [][]byte{id, frame}, true, nil
+
+	case Dish:
+		frame, group, err := sock.RecvDishFrame()
+		if err != nil {
+			return nil, true, err
+		}
+		return [][]byte{[]byte(group), frame}, true, nil
+
+	case Client, Gather, Scatter, Radio:
+		frame, _, err := sock.RecvFrame()
+		if err != nil {
+			return nil, true, err
+		}
+		return [][]byte{frame}, true, nil
+
+	default:
+		return nil, false, nil
+	}
+}
+
+// sendDraftMessage sends a message on a draft socket. Server
+// messages must be a routing id followed by a single frame,
+// and Radio messages a group followed by a single frame. The
+// thread safe socket types do not support multi part messages.
+func sendDraftMessage(sock *Sock, msg [][]byte) (bool, error) {
+	switch sock.GetType() {
+	case Server:
+		if len(msg) != 2 {
+			return true, ErrMultiPartUnsupported
+		}
+		if len(msg[0]) != 4 {
+			return true, fmt.Errorf("invalid routing id length %d", len(msg[0]))
+		}
+		return true, sock.SendServerFrame(msg[1], binary.BigEndian.Uint32(msg[0]))
+
+	case Radio:
+		if len(msg) != 2 {
+			return true, ErrMultiPartUnsupported
+		}
+		return true, sock.SendRadioFrame(msg[1], string(msg[0]))
+
+	case Client, Gather, Scatter, Dish:
+		if len(msg) != 1 {
+			return true, ErrMultiPartUnsupported
+		}
+		return true, sock.SendFrame(msg[0], FlagNone)
+
+	default:
+		return false, nil
+	}
+}
+
+// isDraftSock reports whether sock is one of the
+// draft socket types.
+func isDraftSock(sock *Sock) bool {
+	switch sock.GetType() {
+	case Server, Radio, Client, Gather, Scatter, Dish:
+		return true
+	default:
+		return false
+	}
+}
+
+// execDraftCommand runs commands that are only valid
+// for draft socket types.
+func execDraftCommand(sock *Sock, verb string, args [][]byte) ([][]byte, bool) {
+	switch verb {
+	case "join", "leave":
+		if len(args) != 1 {
+			return channelerReply(ErrActorCmd), true
+		}
+		if sock.GetType() != Dish {
+			return channelerReply(ErrInvalidSockType), true
+		}
+		if verb == "join" {
+			return channelerReply(sock.Join(string(args[0]))), true
+		}
+		return channelerReply(sock.Leave(string(args[0]))), true
+
+	default:
+		return nil, false
+	}
+}
+
+// NewServerChanneler creates a new Channeler wrapping a
+// Server socket. The socket will bind by default. Messages
+// on RecvChan and SendChan are a 4 byte big endian routing
+// id followed by a single frame.
+func NewServerChanneler(endpoints string, options ...SockOption) *Channeler {
+	return newChanneler(Server, endpoints, nil, options)
+}
+
+// NewClientChanneler creates a new Channeler wrapping a
+// Client socket. The socket will connect by default.
+func NewClientChanneler(endpoints string, options ...SockOption) *Channeler {
+	return newChanneler(Client, endpoints, nil, options)
+}
+
+// NewRadioChanneler creates a new Channeler wrapping a Radio
+// socket. The socket will bind by default. Messages on
+// SendChan are a group followed by a single frame.
+func NewRadioChanneler(endpoints string, options ...SockOption) *Channeler {
+	return newChanneler(Radio, endpoints, nil, options)
+}
+
+// NewDishChanneler creates a new Channeler wrapping a Dish
+// socket. Along with an endpoint list it accepts a list of
+// groups to join and/or socket options (discriminated by type).
+// Group strings are split on commas, as Sub topics are. The
+// socket will connect by default. Messages on RecvChan are
+// a group followed by a single frame.
+func NewDishChanneler(endpoints string, varargs ...interface{}) *Channeler {
+	groups := []string{}
+	options := []SockOption{}
+	var err error
+
+	for _, arg := range varargs {
+		switch x := arg.(type) {
+		case string:
+			groups = append(groups, strings.Split(x, ",")...)
+		case SockOption:
+			options = append(options, x)
+		default:
+			err = fmt.Errorf("Don't know how to handle a %T argument to NewDishChanneler", arg)
+		}
+	}
+
+	channeler := newChanneler(Dish, endpoints, groups, options)
+
+	if err != nil {
+		go func() { channeler.errChan <- err }()
+	}
+	return channeler
+}
+
+// NewScatterChanneler creates a new Channeler wrapping a
+// Scatter socket. The socket will connect by default.
+func NewScatterChanneler(endpoints string, options ...SockOption) *Channeler {
+	return newChanneler(Scatter, endpoints, nil, options)
+}
+
+// NewGatherChanneler creates a new Channeler wrapping a
+// Gather socket. The socket will bind by default.
+func NewGatherChanneler(endpoints string, options ...SockOption) *Channeler {
+	return newChanneler(Gather, endpoints, nil, options)
+}
@@ -0,0 +1,114 @@
+//go:build draft
+// +build draft
+
+package goczmq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientServerChanneler(t *testing.T) {
+	server := NewServerChanneler("inproc://channelerclientserver")
+	defer server.Destroy()
+
+	client := NewClientChanneler("inproc://channelerclientserver")
+	defer client.Destroy()
+
+	client.SendChan <- [][]byte{[]byte("hello")}
+
+	var request [][]byte
+	select {
+	case request = <-server.RecvChan:
+	case err := <-server.ErrChan:
+		t.Fatal(err)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	if want, got := 4, len(request[0]); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if want, got := "hello", string(request[1]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	server.SendChan <- [][]byte{request[0], []byte("world")}
+
+	select {
+	case resp := <-client.RecvChan:
+		if want, got := "world", string(resp[0]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+
+	client.SendChan <- [][]byte{[]byte("multi"), []byte("part")}
+	select {
+	case err := <-client.ErrChan:
+		assertEqual(t, ErrMultiPartUnsupported, err)
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+
+	err := client.Join("group")
+	assertEqual(t, ErrInvalidSockType, err)
+}
+
+func TestRadioDishChanneler(t *testing.T) {
+	radio := NewRadioChanneler("tcp://127.0.0.1:31337")
+	defer radio.Destroy()
+
+	dish := NewDishChanneler("tcp://127.0.0.1:31337", "weather")
+	defer dish.Destroy()
+
+	err := dish.Join("sports")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dish.Leave("sports")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(time.Second * 2)
+	for {
+		radio.SendChan <- [][]byte{[]byte("sports"), []byte("goal")}
+		radio.SendChan <- [][]byte{[]byte("weather"), []byte("rain")}
+
+		select {
+		case resp := <-dish.RecvChan:
+			if want, got := "weather", string(resp[0]); want != got {
+				t.Errorf("want '%s', got '%s'", want, got)
+			}
+			if want, got := "rain", string(resp[1]); want != got {
+				t.Errorf("want '%s', got '%s'", want, got)
+			}
+			return
+		case <-time.After(time.Millisecond * 10):
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+}
+
+func TestScatterGatherChanneler(t *testing.T) {
+	gather := NewGatherChanneler("inproc://channelerscattergather")
+	defer gather.Destroy()
+
+	scatter := NewScatterChanneler("inproc://channelerscattergather")
+	defer scatter.Destroy()
+
+	scatter.SendChan <- [][]byte{[]byte("hello")}
+
+	select {
+	case resp := <-gather.RecvChan:
+		if want, got := "hello", string(resp[0]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+}
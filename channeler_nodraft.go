@@ -0,0 +1,29 @@
+//go:build !draft
+// +build !draft
+
+package goczmq
+
+// attachDraftSock is a no-op without the draft build tag.
+func attachDraftSock(sock *Sock, endpoints string, groups []string) (bool, error) {
+	return false, nil
+}
+
+// recvDraftMessage is a no-op without the draft build tag.
+func recvDraftMessage(sock *Sock) ([][]byte, bool, error) {
+	return nil, false, nil
+}
+
+// sendDraftMessage is a no-op without the draft build tag.
+func sendDraftMessage(sock *Sock, msg [][]byte) (bool, error) {
+	return false, nil
+}
+
+// isDraftSock always returns false without the draft build tag.
+func isDraftSock(sock *Sock) bool {
+	return false
+}
+
+// execDraftCommand is a no-op without the draft build tag.
+func execDraftCommand(sock *Sock, verb string, args [][]byte) ([][]byte, bool) {
+	return nil, false
+}
@@ -112,6 +112,156 @@ func TestPubSubChannelerNoInitialSubscription(t *testing.T) {
 	}
 }
 
+func TestPubSubChannelerBinaryTopic(t *testing.T) {
+	pub := NewXPubChanneler("inproc://channelerpubsubbinary")
+	defer pub.Destroy()
+
+	sub := NewSubChanneler("inproc://channelerpubsubbinary")
+	defer sub.Destroy()
+
+	topic := "a topic\x00\xff"
+	err := sub.Subscribe(topic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case resp := <-pub.RecvChan:
+		if want, got := "\x01"+topic, string(resp[0]); want != got {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+
+	pub.SendChan <- [][]byte{[]byte("a topic"), []byte("message")}
+	pub.SendChan <- [][]byte{[]byte(topic), []byte("message")}
+	select {
+	case resp := <-sub.RecvChan:
+		if want, got := topic, string(resp[0]); want != got {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+}
+
+func TestSubChannelerConstructorBinaryTopic(t *testing.T) {
+	pub := NewXPubChanneler("inproc://channelerctorbinary")
+	defer pub.Destroy()
+
+	topic := "a,topic\x00\xff"
+	sub := NewSubChanneler("inproc://channelerctorbinary", []byte(topic))
+	defer sub.Destroy()
+
+	select {
+	case resp := <-pub.RecvChan:
+		if want, got := "\x01"+topic, string(resp[0]); want != got {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+
+	pub.SendChan <- [][]byte{[]byte("a"), []byte("message")}
+	pub.SendChan <- [][]byte{[]byte(topic), []byte("message")}
+	select {
+	case resp := <-sub.RecvChan:
+		if want, got := topic, string(resp[0]); want != got {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+}
+
+func TestChannelerSubscribeError(t *testing.T) {
+	push := NewPushChanneler("inproc://channelersubscribeerror")
+	defer push.Destroy()
+
+	err := push.Subscribe("a")
+	if err == nil {
+		t.Errorf("expected subscribe on a push socket to fail")
+	}
+}
+
+func TestChannelerEvents(t *testing.T) {
+	router := NewRouterChanneler("tcp://127.0.0.1:31338")
+	defer router.Destroy()
+
+	// The CONNECTED event for an endpoint passed to the
+	// constructor is reported
+	dealer := NewChanneler(ChannelerSock{Type: Dealer, Endpoints: "tcp://127.0.0.1:31338", Events: true})
+	defer dealer.Destroy()
+
+	events, err := dealer.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(time.Second * 2)
+	for {
+		select {
+		case event := <-events:
+			if event.Type != EventConnected {
+				continue
+			}
+			if want, got := "tcp://127.0.0.1:31338", event.Endpoint; want != got {
+				t.Errorf("want '%s', got '%s'", want, got)
+			}
+			return
+		case err := <-dealer.ErrChan:
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+}
+
+func TestChannelerEventsDisabled(t *testing.T) {
+	dealer := NewDealerChanneler("inproc://channelereventsdisabled")
+	defer dealer.Destroy()
+
+	_, err := dealer.Events()
+	assertEqual(t, ErrEventsDisabled, err)
+}
+
+func TestChannelerEventsNotDrained(t *testing.T) {
+	pull := NewPullChanneler("inproc://channelereventsnotdrained")
+	defer pull.Destroy()
+
+	// Nothing listens on the tcp endpoint, so the push socket
+	// reports a steady stream of CONNECT_RETRIED events that
+	// nobody reads. Immediate keeps messages off the pending
+	// tcp connection.
+	push := NewChanneler(ChannelerSock{
+		Type:      Push,
+		Endpoints: "inproc://channelereventsnotdrained,tcp://127.0.0.1:31339",
+		Options:   []SockOption{SockSetImmediate(1), SockSetReconnectIvl(1)},
+		Events:    true,
+	})
+	defer push.Destroy()
+
+	time.Sleep(time.Millisecond * 200)
+
+	for i := 0; i < 2; i++ {
+		push.SendChan <- [][]byte{[]byte("hello")}
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-pull.RecvChan:
+			if want, got := "hello", string(msg[0]); want != got {
+				t.Errorf("want '%s', got '%s'", want, got)
+			}
+		case err := <-push.ErrChan:
+			t.Fatal(err)
+		case <-time.After(time.Second * 2):
+			t.Fatal("timeout")
+		}
+	}
+}
+
 func TestPubSubChannelerOptionError(t *testing.T) {
 	sub := NewSubChanneler("inproc://channelerpubsub2", 32)
 	defer sub.Destroy()
@@ -158,6 +308,37 @@ func TestDealerRouterChannelerAttachError(t *testing.T) {
 	}
 }
 
+func TestChannelerSetOptionAfterActorExit(t *testing.T) {
+	dealer := NewDealerChanneler("bad endpoint")
+	defer dealer.Destroy()
+
+	assertEqual(t, ErrSockAttach, <-dealer.ErrChan)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2; i++ {
+			if err := dealer.SetOption(SockSetSndhwm(10)); err != ErrActorCmd {
+				t.Errorf("want %v, got %v", ErrActorCmd, err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 2):
+		t.Errorf("SetOption blocked after the actor exited")
+	}
+
+	// The channeler goroutine exits rather than waiting on a
+	// command pipe that has no peer
+	select {
+	case <-dealer.exited:
+	case <-time.After(time.Second * 2):
+		t.Errorf("channeler goroutine did not exit after the actor exited")
+	}
+}
+
 func TestDealerRouterChannelerEmptyEndpointsError(t *testing.T) {
 	dealer := NewDealerChanneler("")
 	defer dealer.Destroy()
@@ -200,6 +381,58 @@ func TestDealerChannelerRecvChanIsClosedOnDestroy(t *testing.T) {
 
 }
 
+func TestChannelerEndpointCommands(t *testing.T) {
+	pull := NewPullChanneler("inproc://channelercommands1")
+	defer pull.Destroy()
+
+	port, err := pull.Bind("inproc://channelercommands2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 0, port; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+
+	push := NewPushChanneler("inproc://channelercommands1")
+	defer push.Destroy()
+
+	err = push.Disconnect("inproc://channelercommands1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = push.Connect("inproc://channelercommands2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = push.SetOption(SockSetSndhwm(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	push.SendChan <- [][]byte{[]byte("hello")}
+	select {
+	case resp := <-pull.RecvChan:
+		if want, got := "hello", string(resp[0]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+
+	err = pull.Unbind("inproc://channelercommands2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = pull.Unbind("inproc://channelercommands2")
+	assertEqual(t, ErrUnbind, err)
+
+	_, err = pull.Bind("bogus://bogus")
+	assertEqual(t, ErrBind, err)
+}
+
 func ExampleChanneler_output() {
 	// create a dealer channeler
 	dealer := NewDealerChanneler("inproc://channelerdealerrouter")
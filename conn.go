@@ -0,0 +1,815 @@
+package goczmq
+
+/*
+#include "czmq.h"
+*/
+import "C"
+
+import (
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Addr is the net.Addr of a Conn or Listener. Its network
+// is "zmq" and its string form is a ZeroMQ endpoint, or the
+// hex encoded identity of a peer accepted by a Listener.
+type Addr struct {
+	Endpoint string
+}
+
+// Network satisfies net.Addr
+func (a Addr) Network() string {
+	return "zmq"
+}
+
+// String satisfies net.Addr
+func (a Addr) String() string {
+	return a.Endpoint
+}
+
+// sockAddr returns the last endpoint a socket bound to
+func sockAddr(sock *Sock) Addr {
+	endpoint := C.zsock_endpoint(sock.zsockT)
+	if endpoint == nil {
+		return Addr{}
+	}
+	return Addr{Endpoint: C.GoString(endpoint)}
+}
+
+// rawFraming passes frames through as an unstructured byte
+// stream. Each Write is sent as a single frame message.
+type rawFraming struct{}
+
+// Encode satisfies Framing
+func (rawFraming) Encode(dst []byte, msg [][]byte) ([]byte, error) {
+	for _, frame := range msg {
+		dst = append(dst, frame...)
+	}
+	return dst, nil
+}
+
+// Decode satisfies Framing
+func (rawFraming) Decode(src []byte) ([][]byte, int, error) {
+	if len(src) == 0 {
+		return nil, 0, nil
+	}
+	return [][]byte{append([]byte{}, src...)}, len(src), nil
+}
+
+// deadline holds a read or write deadline that can be
+// changed from any goroutine
+type deadline struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	d.t = t
+	d.mu.Unlock()
+}
+
+// timeout returns the milliseconds left until the deadline,
+// -1 if there is none, or 0 if it has passed.
+func (d *deadline) timeout() int {
+	d.mu.Lock()
+	t := d.t
+	d.mu.Unlock()
+
+	if t.IsZero() {
+		return -1
+	}
+	wait := time.Until(t)
+	if wait <= 0 {
+		return 0
+	}
+	return int((wait + time.Millisecond - 1) / time.Millisecond)
+}
+
+// Conn is a net.Conn over a Pair, Dealer or Client socket. The
+// messages received are read as a byte stream, and each Write is
+// sent as a single frame message. Read and Write may be called
+// from different goroutines: a pending Read is woken to hand the
+// socket over to a Write, since a socket must not be used from
+// two threads at once.
+type Conn struct {
+	rw      *ReadWriter
+	mu      sync.Mutex
+	cond    sync.Cond
+	busy    bool
+	pending int
+	closed  atomic.Bool
+	local   Addr
+	remote  Addr
+	read    deadline
+	write   deadline
+}
+
+// NewConn wraps sock in a Conn. The Conn should now be considered
+// responsible for the Sock. remote is reported by RemoteAddr.
+func NewConn(sock *Sock, remote string) (*Conn, error) {
+	switch sock.GetType() {
+	case Pair, Dealer:
+	default:
+		if !isDraftConnType(sock.GetType()) {
+			return nil, ErrInvalidSockType
+		}
+	}
+
+	rw, err := NewReadWriter(sock)
+	if err != nil {
+		return nil, err
+	}
+	rw.SetFraming(rawFraming{})
+
+	// Deadlines, Write and Close interrupt a Read that is
+	// already waiting
+	_, err = rw.poller.wakeSignal()
+	if err != nil {
+		rw.poller.Destroy()
+		return nil, err
+	}
+
+	c := &Conn{
+		rw:     rw,
+		local:  sockAddr(sock),
+		remote: Addr{Endpoint: remote},
+	}
+	c.cond.L = &c.mu
+	return c, nil
+}
+
+// Dial creates a socket of sockType connected to endpoint
+// and returns it as a Conn.
+func Dial(sockType int, endpoint string, options ...SockOption) (*Conn, error) {
+	sock := NewSock(sockType, options...)
+	err := sock.Connect(endpoint)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	c, err := NewConn(sock, endpoint)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Read satisfies net.Conn
+func (c *Conn) Read(p []byte) (int, error) {
+	c.lock()
+	defer c.unlock()
+
+	for {
+		if c.closed.Load() {
+			return 0, net.ErrClosed
+		}
+
+		timeout := c.read.timeout()
+		if timeout == 0 {
+			return 0, os.ErrDeadlineExceeded
+		}
+		c.rw.SetTimeout(timeout)
+
+		n, err := c.rw.Read(p)
+		switch err {
+		case ErrInterrupted:
+			// Hand the socket to a pending Write or Close
+			c.unlock()
+			c.lock()
+		case ErrTimeout:
+		default:
+			return n, err
+		}
+	}
+}
+
+// Write satisfies net.Conn
+func (c *Conn) Write(p []byte) (int, error) {
+	c.acquire()
+	defer c.unlock()
+
+	if c.closed.Load() {
+		return 0, net.ErrClosed
+	}
+
+	timeout := c.write.timeout()
+	if timeout == 0 {
+		return 0, os.ErrDeadlineExceeded
+	}
+	c.rw.sock.SetOption(SockSetSndtimeo(timeout))
+
+	n, err := c.rw.Write(p)
+	if err == syscall.EAGAIN {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return n, err
+}
+
+// Close satisfies net.Conn. It destroys the socket.
+func (c *Conn) Close() error {
+	if c.closed.Swap(true) {
+		return net.ErrClosed
+	}
+
+	c.acquire()
+	defer c.unlock()
+
+	c.rw.Destroy()
+	return nil
+}
+
+// LocalAddr satisfies net.Conn
+func (c *Conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr satisfies net.Conn
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline satisfies net.Conn
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline satisfies net.Conn. It also applies
+// to a Read that is already waiting.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.read.set(t)
+	if !c.closed.Load() {
+		c.rw.poller.Interrupt()
+	}
+	return nil
+}
+
+// SetWriteDeadline satisfies net.Conn
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.write.set(t)
+	return nil
+}
+
+// acquire takes the socket from a pending Read for a
+// Write or Close.
+func (c *Conn) acquire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending++
+	c.rw.poller.Interrupt()
+	for c.busy {
+		c.cond.Wait()
+	}
+	c.pending--
+	c.busy = true
+}
+
+// lock acquires the socket for a Read, giving way to any
+// Write or Close that is waiting for it.
+func (c *Conn) lock() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.busy || c.pending > 0 {
+		c.cond.Wait()
+	}
+	c.busy = true
+}
+
+// unlock releases the socket taken by lock or acquire.
+func (c *Conn) unlock() {
+	c.mu.Lock()
+	c.busy = false
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+// PeerConn is a virtual connection to a peer accepted by a
+// Listener or a StreamServer. It satisfies net.Conn.
+type PeerConn struct {
+	l        *Listener
+	id       []byte
+	mu       sync.Mutex
+	buf      []byte
+	eof      bool
+	closed   bool
+	notify   chan struct{}
+	done     chan struct{}
+	doneOnce sync.Once
+	read     deadline
+	write    deadline
+	remote   Addr
+}
+
+// listenerWrite is a message queued for the Listener goroutine
+type listenerWrite struct {
+	msg   [][]byte
+	close []byte
+	reply chan error
+}
+
+// peerConnBuffer is the number of unread bytes a PeerConn
+// buffers before its Listener stops receiving
+const peerConnBuffer = 1 << 20
+
+// listenerBacklog is the number of connections a Listener
+// queues for Accept before it refuses new peers
+const listenerBacklog = 128
+
+// Listener is a net.Listener over a Router, Server or Stream
+// socket. It accepts one connection per peer identity, created
+// when the first message from that peer arrives, or for Stream
+// sockets when the peer connects. The socket is owned by a
+// goroutine that delivers messages to connections and sends
+// their writes, woken through its Poller's Interrupt. While a
+// connection has peerConnBuffer or more unread bytes, the
+// Listener stops receiving from the socket until it is read
+// or closed, so a slow reader applies backpressure to every
+// peer through the socket's high water mark. Up to
+// listenerBacklog new connections are queued for Accept; while
+// the backlog is full new peers are refused: Stream peers are
+// disconnected, and messages from other peers are dropped.
+type Listener struct {
+	sock    *Sock
+	poller  *Poller
+	addr    Addr
+	stream  bool
+	conns   map[string]*PeerConn
+	closed  map[string]bool
+	accept  chan *PeerConn
+	paused  *PeerConn
+	mu      sync.Mutex
+	writes  []listenerWrite
+	closing chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewListener wraps sock in a Listener. The Listener should now
+// be considered responsible for the Sock.
+func NewListener(sock *Sock) (*Listener, error) {
+	switch sock.GetType() {
+	case Router, Stream:
+	default:
+		if !isDraftListenerType(sock.GetType()) {
+			return nil, ErrInvalidSockType
+		}
+	}
+
+	poller, err := NewPoller(sock)
+	if err != nil {
+		return nil, err
+	}
+
+	// Writes and Close interrupt the I/O loop's wait
+	_, err = poller.wakeSignal()
+	if err != nil {
+		poller.Destroy()
+		return nil, err
+	}
+
+	l := &Listener{
+		sock:    sock,
+		poller:  poller,
+		addr:    sockAddr(sock),
+		stream:  sock.GetType() == Stream,
+		conns:   make(map[string]*PeerConn),
+		closed:  make(map[string]bool),
+		accept:  make(chan *PeerConn, listenerBacklog),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.run()
+
+	return l, nil
+}
+
+// Listen creates a socket of sockType bound to endpoint
+// and returns it as a Listener.
+func Listen(sockType int, endpoint string, options ...SockOption) (*Listener, error) {
+	sock := NewSock(sockType, options...)
+	_, err := sock.Bind(endpoint)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	l, err := NewListener(sock)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Accept satisfies net.Listener
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.acceptPeer()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// acceptPeer waits for the next connection
+func (l *Listener) acceptPeer() (*PeerConn, error) {
+	select {
+	case <-l.closing:
+		// Queued connections are not accepted after Close
+		return nil, net.ErrClosed
+	default:
+	}
+
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-l.closing:
+		return nil, net.ErrClosed
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close satisfies net.Listener. It destroys the socket, and
+// the accepted connections return io.EOF once drained.
+func (l *Listener) Close() error {
+	err := net.ErrClosed
+	l.once.Do(func() {
+		close(l.closing)
+		l.poller.Interrupt()
+		<-l.done
+		err = nil
+	})
+	return err
+}
+
+// Addr satisfies net.Listener
+func (l *Listener) Addr() net.Addr {
+	return l.addr
+}
+
+// send queues a message for the Listener goroutine and
+// waits for it to be sent. If timeout fires first, the message
+// is dropped from the queue, or if the Listener goroutine has
+// already taken it, its result is awaited, so a message is never
+// sent after send has reported a timeout.
+func (l *Listener) send(w listenerWrite, timeout <-chan time.Time) error {
+	w.reply = make(chan error, 1)
+
+	l.mu.Lock()
+	l.writes = append(l.writes, w)
+	l.mu.Unlock()
+	l.poller.Interrupt()
+
+	select {
+	case err := <-w.reply:
+		return err
+	case <-l.done:
+		return net.ErrClosed
+	case <-timeout:
+	}
+
+	l.mu.Lock()
+	for i, queued := range l.writes {
+		if queued.reply == w.reply {
+			l.writes = append(l.writes[:i], l.writes[i+1:]...)
+			l.mu.Unlock()
+			return os.ErrDeadlineExceeded
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case err := <-w.reply:
+		return err
+	case <-l.done:
+		return net.ErrClosed
+	}
+}
+
+// run is the goroutine that owns the Listener's socket
+func (l *Listener) run() {
+	defer close(l.done)
+	defer l.sock.Destroy()
+	defer l.poller.Destroy()
+	defer func() {
+		for _, c := range l.conns {
+			c.deliver(nil, true)
+		}
+	}()
+
+	for {
+		s, err := l.poller.Wait(-1)
+		switch {
+		case err == ErrInterrupted:
+			select {
+			case <-l.closing:
+				return
+			default:
+			}
+			l.runWrites()
+			l.resume()
+
+		case err != nil:
+			return
+
+		case s != nil:
+			msg, err := recvChannelerMessage(s)
+			if err != nil || len(msg) < 2 {
+				continue
+			}
+
+			id := string(msg[0])
+			c, ok := l.conns[id]
+
+			if l.stream && len(msg[1]) == 0 {
+				// A zero length frame on a Stream socket
+				// reports a connect or a disconnect
+				switch {
+				case l.closed[id]:
+					delete(l.closed, id)
+				case ok:
+					delete(l.conns, id)
+					c.deliver(nil, true)
+				default:
+					l.newConn(msg[0])
+				}
+				continue
+			}
+
+			if !ok {
+				if l.closed[id] || !l.newConn(msg[0]) {
+					// The peer was closed or refused
+					continue
+				}
+				c = l.conns[id]
+			}
+
+			var data []byte
+			for _, frame := range msg[1:] {
+				data = append(data, frame...)
+			}
+			c.deliver(data, false)
+
+			if c.full() {
+				// Stop receiving until the connection is read
+				l.poller.Remove(l.sock)
+				l.paused = c
+			}
+		}
+	}
+}
+
+// resume receives from the socket again once the connection
+// that paused the Listener has been read or closed
+func (l *Listener) resume() {
+	if l.paused == nil || l.paused.full() {
+		return
+	}
+	l.paused = nil
+	l.poller.Add(l.sock)
+}
+
+// newConn creates a connection for a peer and queues it to
+// be accepted. It returns false if the backlog is full, in
+// which case a Stream peer is disconnected.
+func (l *Listener) newConn(id []byte) bool {
+	c := &PeerConn{
+		l:      l,
+		id:     id,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		remote: Addr{Endpoint: hex.EncodeToString(id)},
+	}
+
+	select {
+	case l.accept <- c:
+	default:
+		if l.stream {
+			// A zero length frame disconnects a Stream peer
+			l.closed[string(id)] = true
+			sendChannelerMessage(l.sock, [][]byte{id, {}})
+		}
+		return false
+	}
+	l.conns[string(id)] = c
+	return true
+}
+
+// runWrites sends the queued writes
+func (l *Listener) runWrites() {
+	l.mu.Lock()
+	writes := l.writes
+	l.writes = nil
+	l.mu.Unlock()
+
+	for _, w := range writes {
+		if w.close != nil {
+			delete(l.conns, string(w.close))
+			if !l.stream {
+				w.reply <- nil
+				continue
+			}
+
+			// A zero length frame disconnects a Stream peer
+			l.closed[string(w.close)] = true
+			w.reply <- sendChannelerMessage(l.sock, [][]byte{w.close, {}})
+			continue
+		}
+		w.reply <- sendChannelerMessage(l.sock, w.msg)
+	}
+}
+
+// deliver appends data to the connection's read buffer
+func (c *PeerConn) deliver(data []byte, eof bool) {
+	c.mu.Lock()
+	c.buf = append(c.buf, data...)
+	c.eof = c.eof || eof
+	c.mu.Unlock()
+	c.wake()
+
+	if eof {
+		c.end()
+	}
+}
+
+// full reports whether the connection has peerConnBuffer or
+// more unread bytes and has not been closed
+func (c *PeerConn) full() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.closed && len(c.buf) >= peerConnBuffer
+}
+
+// end closes the channel returned by Closed
+func (c *PeerConn) end() {
+	c.doneOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// ID returns the routing id of the peer. For Server
+// sockets it is 4 bytes in big endian order.
+func (c *PeerConn) ID() []byte {
+	return c.id
+}
+
+// Closed returns a channel that is closed when the peer
+// disconnects, which is only reported for Stream sockets,
+// or when the connection or its Listener is closed. Data
+// received before that can still be read.
+func (c *PeerConn) Closed() <-chan struct{} {
+	return c.done
+}
+
+// wake wakes a pending Read
+func (c *PeerConn) wake() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Read satisfies net.Conn
+func (c *PeerConn) Read(p []byte) (int, error) {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return 0, net.ErrClosed
+		}
+		if len(c.buf) > 0 {
+			full := len(c.buf) >= peerConnBuffer
+			n := copy(p, c.buf)
+			c.buf = c.buf[n:]
+			resume := full && len(c.buf) < peerConnBuffer
+			c.mu.Unlock()
+
+			if resume {
+				// Wake the Listener in case this
+				// connection paused it
+				c.l.poller.Interrupt()
+			}
+			return n, nil
+		}
+		eof := c.eof
+		c.mu.Unlock()
+
+		if eof {
+			return 0, io.EOF
+		}
+
+		timeout := c.read.timeout()
+		if timeout == 0 {
+			return 0, os.ErrDeadlineExceeded
+		}
+
+		if timeout == -1 {
+			<-c.notify
+			continue
+		}
+
+		timer := time.NewTimer(time.Duration(timeout) * time.Millisecond)
+		select {
+		case <-c.notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// Write satisfies net.Conn. Each Write is sent to the
+// peer as a single frame message. Empty writes are not
+// sent to Stream peers, as they would disconnect them.
+func (c *PeerConn) Write(p []byte) (int, error) {
+	if len(p) == 0 && c.l.stream {
+		return 0, nil
+	}
+
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return 0, net.ErrClosed
+	}
+
+	timeout := c.write.timeout()
+	if timeout == 0 {
+		return 0, os.ErrDeadlineExceeded
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(time.Duration(timeout) * time.Millisecond)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	msg := [][]byte{c.id, append([]byte{}, p...)}
+	err := c.l.send(listenerWrite{msg: msg}, expired)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close satisfies net.Conn. A Stream peer is disconnected;
+// for other sockets later messages from the same peer are
+// accepted as a new connection.
+func (c *PeerConn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return net.ErrClosed
+	}
+	c.closed = true
+	c.mu.Unlock()
+	c.wake()
+	c.end()
+
+	err := c.l.send(listenerWrite{close: c.id}, nil)
+	if err == net.ErrClosed {
+		return nil
+	}
+	return err
+}
+
+// LocalAddr satisfies net.Conn
+func (c *PeerConn) LocalAddr() net.Addr {
+	return c.l.addr
+}
+
+// RemoteAddr satisfies net.Conn
+func (c *PeerConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline satisfies net.Conn
+func (c *PeerConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline satisfies net.Conn. It also applies
+// to a Read that is already waiting.
+func (c *PeerConn) SetReadDeadline(t time.Time) error {
+	c.read.set(t)
+	c.wake()
+	return nil
+}
+
+// SetWriteDeadline satisfies net.Conn
+func (c *PeerConn) SetWriteDeadline(t time.Time) error {
+	c.write.set(t)
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build draft
+// +build draft
+
+package goczmq
+
+// isDraftConnType reports whether a draft socket type
+// can be wrapped in a Conn.
+func isDraftConnType(sockType int) bool {
+	return sockType == Client
+}
+
+// isDraftListenerType reports whether a draft socket type
+// can be wrapped in a Listener.
+func isDraftListenerType(sockType int) bool {
+	return sockType == Server
+}
@@ -0,0 +1,42 @@
+//go:build draft
+// +build draft
+
+package goczmq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientServerConn(t *testing.T) {
+	l, err := Listen(Server, "inproc://clientserverconn")
+	require.NoError(t, err)
+	defer l.Close()
+
+	c, err := Dial(Client, "inproc://clientserverconn")
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	server, err := l.Accept()
+	require.NoError(t, err)
+
+	b := make([]byte, 16)
+	n, err := server.Read(b)
+	require.NoError(t, err)
+	if want, got := "hello", string(b[:n]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	_, err = server.Write([]byte("world"))
+	require.NoError(t, err)
+
+	n, err = c.Read(b)
+	require.NoError(t, err)
+	if want, got := "world", string(b[:n]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+}
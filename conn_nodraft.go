@@ -0,0 +1,14 @@
+//go:build !draft
+// +build !draft
+
+package goczmq
+
+// isDraftConnType is always false without the draft build tag.
+func isDraftConnType(sockType int) bool {
+	return false
+}
+
+// isDraftListenerType is always false without the draft build tag.
+func isDraftListenerType(sockType int) bool {
+	return false
+}
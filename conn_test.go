@@ -0,0 +1,346 @@
+package goczmq
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnListener(t *testing.T) {
+	l, err := Listen(Router, "inproc://connlistener")
+	require.NoError(t, err)
+	defer l.Close()
+
+	c, err := Dial(Dealer, "inproc://connlistener")
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Write([]byte("hello\n"))
+	require.NoError(t, err)
+
+	server, err := l.Accept()
+	require.NoError(t, err)
+	defer server.Close()
+
+	line, err := bufio.NewReader(server).ReadString('\n')
+	require.NoError(t, err)
+	if want, got := "hello\n", line; want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	_, err = server.Write([]byte("wor"))
+	require.NoError(t, err)
+	_, err = server.Write([]byte("ld\n"))
+	require.NoError(t, err)
+
+	line, err = bufio.NewReader(c).ReadString('\n')
+	require.NoError(t, err)
+	if want, got := "world\n", line; want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	if want, got := "inproc://connlistener", l.Addr().String(); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+}
+
+func TestConnDeadlines(t *testing.T) {
+	l, err := Listen(Router, "inproc://conndeadlines")
+	require.NoError(t, err)
+	defer l.Close()
+
+	c, err := Dial(Dealer, "inproc://conndeadlines")
+	require.NoError(t, err)
+	defer c.Close()
+
+	err = c.SetReadDeadline(time.Now().Add(time.Millisecond * 10))
+	require.NoError(t, err)
+
+	b := make([]byte, 16)
+	_, err = c.Read(b)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("want '%v', got '%v'", os.ErrDeadlineExceeded, err)
+	}
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected a timeout net.Error")
+	}
+
+	err = c.SetReadDeadline(time.Time{})
+	require.NoError(t, err)
+
+	// A Write from another goroutine must not wait for the Read
+	result := make(chan error)
+	go func() {
+		_, err := c.Read(b)
+		result <- err
+	}()
+
+	time.Sleep(time.Millisecond * 10)
+	_, err = c.Write([]byte("ping"))
+	require.NoError(t, err)
+
+	server, err := l.Accept()
+	require.NoError(t, err)
+
+	ping := make([]byte, 16)
+	n, err := server.Read(ping)
+	require.NoError(t, err)
+	if want, got := "ping", string(ping[:n]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	_, err = server.Write([]byte("pong"))
+	require.NoError(t, err)
+
+	select {
+	case err := <-result:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	if want, got := "pong", string(b[:4]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	// Moving the deadline applies to a Read already waiting
+	go func() {
+		_, err := server.Read(b)
+		result <- err
+	}()
+
+	time.Sleep(time.Millisecond * 10)
+	server.SetReadDeadline(time.Now())
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("want '%v', got '%v'", os.ErrDeadlineExceeded, err)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	err = c.Close()
+	require.NoError(t, err)
+
+	_, err = c.Read(b)
+	if want, got := net.ErrClosed, err; want != got {
+		t.Errorf("want '%v', got '%v'", want, got)
+	}
+}
+
+func TestListenerClose(t *testing.T) {
+	l, err := Listen(Router, "inproc://listenerclose")
+	require.NoError(t, err)
+
+	result := make(chan error)
+	go func() {
+		_, err := l.Accept()
+		result <- err
+	}()
+
+	err = l.Close()
+	require.NoError(t, err)
+
+	select {
+	case err := <-result:
+		if want, got := net.ErrClosed, err; want != got {
+			t.Errorf("want '%v', got '%v'", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	err = l.Close()
+	if want, got := net.ErrClosed, err; want != got {
+		t.Errorf("want '%v', got '%v'", want, got)
+	}
+
+	pub, err := NewPub("inproc://listenerclosepub")
+	require.NoError(t, err)
+	defer pub.Destroy()
+
+	_, err = NewListener(pub)
+	assertEqual(t, ErrInvalidSockType, err)
+}
+
+func TestListenerWriteTimeout(t *testing.T) {
+	l, err := Listen(Router, "inproc://listenerwritetimeout")
+	require.NoError(t, err)
+	defer l.Close()
+
+	dealer, err := NewDealer("inproc://listenerwritetimeout")
+	require.NoError(t, err)
+	defer dealer.Destroy()
+	dealer.SetOption(SockSetRcvtimeo(200))
+
+	err = dealer.SendFrame([]byte("hello"), FlagNone)
+	require.NoError(t, err)
+
+	c, err := l.acceptPeer()
+	require.NoError(t, err)
+
+	// With the timeout already expired, each write either times
+	// out before the Listener takes it or waits for its result
+	expired := make(chan time.Time)
+	close(expired)
+
+	sent := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		msg := strconv.Itoa(i)
+		err := l.send(listenerWrite{msg: [][]byte{c.id, []byte(msg)}}, expired)
+		switch err {
+		case nil:
+			sent[msg] = true
+		case os.ErrDeadlineExceeded:
+		default:
+			t.Fatal(err)
+		}
+	}
+
+	for {
+		frame, _, err := dealer.RecvFrame()
+		if err != nil {
+			break
+		}
+		if !sent[string(frame)] {
+			t.Errorf("write %s was sent after timing out", frame)
+		}
+		delete(sent, string(frame))
+	}
+	if len(sent) != 0 {
+		t.Errorf("writes %v were reported sent but not received", sent)
+	}
+}
+
+func TestListenerBackpressure(t *testing.T) {
+	l, err := Listen(Router, "inproc://listenerbackpressure")
+	require.NoError(t, err)
+	defer l.Close()
+
+	c, err := Dial(Dealer, "inproc://listenerbackpressure")
+	require.NoError(t, err)
+	defer c.Close()
+
+	chunk := make([]byte, 64*1024)
+	count := 2*peerConnBuffer/len(chunk) + 1
+	for i := 0; i < count; i++ {
+		_, err = c.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	accepted, err := l.Accept()
+	require.NoError(t, err)
+	server := accepted.(*PeerConn)
+	defer server.Close()
+
+	// The Listener stops receiving once the buffer is full
+	time.Sleep(time.Millisecond * 200)
+	server.mu.Lock()
+	buffered := len(server.buf)
+	server.mu.Unlock()
+	if buffered >= peerConnBuffer+len(chunk) {
+		t.Errorf("want less than %d bytes buffered, got %d", peerConnBuffer+len(chunk), buffered)
+	}
+
+	// Writes are still sent while the Listener is paused
+	_, err = server.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	err = c.SetReadDeadline(time.Now().Add(time.Second * 2))
+	require.NoError(t, err)
+	reply := make([]byte, 5)
+	_, err = c.Read(reply)
+	require.NoError(t, err)
+	if want, got := "hello", string(reply); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	err = server.SetReadDeadline(time.Now().Add(time.Second * 2))
+	require.NoError(t, err)
+	total := 0
+	buf := make([]byte, len(chunk))
+	for total < count*len(chunk) {
+		n, err := server.Read(buf)
+		require.NoError(t, err)
+		total += n
+	}
+}
+
+func TestListenerBacklog(t *testing.T) {
+	l, err := Listen(Router, "inproc://listenerbacklog")
+	require.NoError(t, err)
+	defer l.Close()
+
+	c, err := Dial(Dealer, "inproc://listenerbacklog")
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Write([]byte("first"))
+	require.NoError(t, err)
+
+	server, err := l.Accept()
+	require.NoError(t, err)
+	defer server.Close()
+
+	// Fill the backlog and more without accepting
+	for i := 0; i < listenerBacklog+5; i++ {
+		peer, err := Dial(Dealer, "inproc://listenerbacklog")
+		require.NoError(t, err)
+		defer peer.Close()
+
+		_, err = peer.Write([]byte("hello"))
+		require.NoError(t, err)
+	}
+
+	// The accepted connection keeps working meanwhile
+	_, err = c.Write([]byte("second"))
+	require.NoError(t, err)
+
+	err = server.SetReadDeadline(time.Now().Add(time.Second * 2))
+	require.NoError(t, err)
+
+	b := make([]byte, 16)
+	var received []byte
+	for len(received) < len("firstsecond") {
+		n, err := server.Read(b)
+		require.NoError(t, err)
+		received = append(received, b[:n]...)
+	}
+	if want, got := "firstsecond", string(received); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	// Let the Listener refuse the peers beyond the backlog
+	// before making room in it
+	timeout := time.After(time.Second * 2)
+	for len(l.accept) < listenerBacklog {
+		select {
+		case <-timeout:
+			t.Fatal("timeout")
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+	time.Sleep(time.Millisecond * 200)
+
+	for i := 0; i < listenerBacklog; i++ {
+		peer, err := l.Accept()
+		require.NoError(t, err)
+		defer peer.Close()
+	}
+
+	select {
+	case <-l.accept:
+		t.Errorf("expected peers beyond the backlog to be refused")
+	case <-time.After(time.Millisecond * 100):
+	}
+}
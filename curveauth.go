@@ -0,0 +1,93 @@
+package goczmq
+
+import (
+	"sync"
+
+	"github.com/nofeaturesonlybugs/z85"
+)
+
+// CurveAuthenticator is an Authenticator for CURVE clients backed
+// by a CertStore, such as one created with NewCertStoreInMemory
+// from keys kept outside the filesystem. Use it with a ZapHandler
+// in place of Auth.Curve. Certificates can be inserted and revoked
+// while the handler is running. An authorised client's User-Id is
+// its public key text, and its certificate's meta data is attached
+// as ZAP metadata.
+type CurveAuthenticator struct {
+	mu      sync.Mutex
+	store   *CertStore
+	revoked map[string]bool
+}
+
+// NewCurveAuthenticator creates a CurveAuthenticator for the
+// certificates in store. While it is in use, certificates should
+// only be added through its Insert method.
+func NewCurveAuthenticator(store *CertStore) *CurveAuthenticator {
+	return &CurveAuthenticator{
+		store:   store,
+		revoked: make(map[string]bool),
+	}
+}
+
+// Insert adds a certificate to the store, taking ownership of it
+// as CertStore.Insert does, and clears any revocation of its public
+// key. If the store already holds a certificate with the same
+// public key, that one is kept and cert is destroyed.
+func (c *CurveAuthenticator) Insert(cert *Cert) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cert.PublicText()
+	delete(c.revoked, key)
+
+	if c.store.Lookup(key) != nil {
+		cert.Destroy()
+		return
+	}
+	c.store.Insert(cert)
+}
+
+// Revoke denies the client with the given public key text,
+// whether or not its certificate is in the store.
+func (c *CurveAuthenticator) Revoke(publicKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.revoked[publicKey] = true
+}
+
+// Authenticate satisfies Authenticator
+func (c *CurveAuthenticator) Authenticate(req *ZapRequest) ZapResponse {
+	if req.Mechanism != "CURVE" || len(req.Credentials) != 1 || len(req.Credentials[0]) != 32 {
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Invalid mechanism"}
+	}
+
+	key, err := z85.Encode(req.Credentials[0])
+	if err != nil {
+		return ZapResponse{StatusCode: ZapStatusInternalError, StatusText: err.Error()}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.revoked[key] {
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Certificate revoked"}
+	}
+
+	cert := c.store.Lookup(key)
+	if cert == nil {
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Certificate not authorized"}
+	}
+
+	metadata := make(map[string]string)
+	for _, name := range cert.MetaKeys() {
+		metadata[name] = cert.Meta(name)
+	}
+
+	return ZapResponse{
+		StatusCode: ZapStatusSuccess,
+		StatusText: "OK",
+		UserID:     key,
+		Metadata:   metadata,
+	}
+}
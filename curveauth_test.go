@@ -0,0 +1,118 @@
+package goczmq
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nofeaturesonlybugs/z85"
+	"github.com/stretchr/testify/require"
+)
+
+func curveRequest(t *testing.T, cert *Cert) *ZapRequest {
+	key, err := z85.Decode(cert.PublicText())
+	require.NoError(t, err)
+	return &ZapRequest{Mechanism: "CURVE", Credentials: [][]byte{key}}
+}
+
+func TestCurveAuthenticator(t *testing.T) {
+	store := NewCertStoreInMemory()
+	defer store.Destroy()
+
+	auth := NewCurveAuthenticator(store)
+
+	cert := NewCert()
+	cert.SetMeta("name", "Brian Knox")
+	key := cert.PublicText()
+	req := curveRequest(t, cert)
+
+	resp := auth.Authenticate(req)
+	assertEqual(t, ZapStatusFailure, resp.StatusCode)
+
+	auth.Insert(cert)
+
+	resp = auth.Authenticate(req)
+	assertEqual(t, ZapStatusSuccess, resp.StatusCode)
+	assertEqual(t, key, resp.UserID)
+	assertEqual(t, "Brian Knox", resp.Metadata["name"])
+
+	auth.Revoke(key)
+	resp = auth.Authenticate(req)
+	assertEqual(t, ZapStatusFailure, resp.StatusCode)
+
+	dup := store.Lookup(key).Dup()
+	auth.Insert(dup)
+	resp = auth.Authenticate(req)
+	assertEqual(t, ZapStatusSuccess, resp.StatusCode)
+
+	resp = auth.Authenticate(&ZapRequest{Mechanism: "PLAIN", Credentials: [][]byte{[]byte("admin"), []byte("Password")}})
+	assertEqual(t, ZapStatusFailure, resp.StatusCode)
+}
+
+func TestCurveAuthenticatorZapHandler(t *testing.T) {
+	store := NewCertStoreInMemory()
+	defer store.Destroy()
+
+	auth := NewCurveAuthenticator(store)
+
+	handler, err := NewZapHandler(auth)
+	require.NoError(t, err)
+	defer handler.Destroy()
+
+	server := NewSock(Pull, SockSetZapDomain("global"))
+	defer server.Destroy()
+	serverCert := NewCert()
+	defer serverCert.Destroy()
+	serverKey := serverCert.PublicText()
+	serverCert.Apply(server)
+	server.SetOption(SockSetCurveServer(1))
+
+	goodClient := NewSock(Push, SockSetCurveServerkey(serverKey))
+	defer goodClient.Destroy()
+	goodClientCert := NewCert()
+	defer goodClientCert.Destroy()
+	goodClientCert.Apply(goodClient)
+	auth.Insert(goodClientCert.Dup())
+
+	revokedClient := NewSock(Push, SockSetCurveServerkey(serverKey))
+	defer revokedClient.Destroy()
+	revokedClientCert := NewCert()
+	defer revokedClientCert.Destroy()
+	revokedClientCert.Apply(revokedClient)
+	auth.Insert(revokedClientCert.Dup())
+	auth.Revoke(revokedClientCert.PublicText())
+
+	port, err := server.Bind("tcp://127.0.0.1:*")
+	require.NoError(t, err)
+
+	poller, err := NewPoller(server)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	err = revokedClient.Connect(fmt.Sprintf("tcp://127.0.0.1:%d", port))
+	require.NoError(t, err)
+
+	err = revokedClient.SendFrame([]byte("Revoked"), FlagNone)
+	require.NoError(t, err)
+
+	s, err := poller.Wait(200)
+	require.NoError(t, err)
+	if s != nil {
+		t.Errorf("expected no message from revoked client")
+	}
+
+	err = goodClient.Connect(fmt.Sprintf("tcp://127.0.0.1:%d", port))
+	require.NoError(t, err)
+
+	err = goodClient.SendFrame([]byte("Hello, Good World!"), FlagNone)
+	require.NoError(t, err)
+
+	s, err = poller.Wait(2000)
+	require.NoError(t, err)
+	if want, have := server, s; want != have {
+		t.Fatalf("want '%#v', have '%#v'", want, have)
+	}
+
+	msg, err := s.RecvMessage()
+	require.NoError(t, err)
+	assertEqual(t, "Hello, Good World!", string(msg[0]))
+}
@@ -0,0 +1,264 @@
+package goczmq
+
+/*
+#include "czmq.h"
+
+void EventPoller_set_sock(zmq_pollitem_t *item, zsock_t *sock) {
+	item->socket = zsock_resolve(sock);
+}
+
+void EventPoller_set_fd(zmq_pollitem_t *item, int fd) {
+	item->fd = fd;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// PollEvent is a socket, file descriptor or connection returned
+// by EventPoller.Wait together with the events (Pollin, Pollout
+// or both) it is ready for. Sock is nil for file descriptors and
+// connections, and Conn is only set for items added with AddConn.
+type PollEvent struct {
+	Sock   *Sock
+	Fd     int
+	Conn   syscall.Conn
+	Events int
+}
+
+// EventPoller is a wrapper around zmq_poll where each socket
+// is registered with an event mask of Pollin, Pollout or both.
+// Unlike Poller, it can wait for sockets to become writable,
+// and Wait returns every ready socket from a single poll. Raw
+// file descriptors and connections such as *net.UDPConn can be
+// polled alongside the sockets. An EventPoller is not safe for
+// concurrent use.
+type EventPoller struct {
+	items     []C.zmq_pollitem_t
+	entries   []PollEvent
+	destroyed bool
+}
+
+// NewEventPoller creates a new EventPoller with no sockets.
+func NewEventPoller() *EventPoller {
+	return &EventPoller{}
+}
+
+// Add adds a socket to be polled for the given events, which
+// must be Pollin, Pollout or Pollin|Pollout.
+func (p *EventPoller) Add(sock *Sock, events int) error {
+	if p.index(sock, -1) != -1 {
+		return fmt.Errorf("socket already added to poller")
+	}
+
+	var item C.zmq_pollitem_t
+	C.EventPoller_set_sock(&item, sock.zsockT)
+	return p.add(item, PollEvent{Sock: sock, Fd: -1}, events)
+}
+
+// AddFd adds a raw file descriptor, such as a pipe or an
+// inotify instance, to be polled for the given events.
+func (p *EventPoller) AddFd(fd int, events int) error {
+	if p.index(nil, fd) != -1 {
+		return fmt.Errorf("file descriptor already added to poller")
+	}
+
+	var item C.zmq_pollitem_t
+	C.EventPoller_set_fd(&item, C.int(fd))
+	return p.add(item, PollEvent{Fd: fd}, events)
+}
+
+// AddConn adds a connection that exposes its file descriptor,
+// such as a *net.UDPConn or *os.File, to be polled for the given
+// events. The connection must stay open while it is registered.
+func (p *EventPoller) AddConn(conn syscall.Conn, events int) error {
+	fd, err := connFd(conn)
+	if err != nil {
+		return err
+	}
+	if p.index(nil, fd) != -1 {
+		return fmt.Errorf("file descriptor already added to poller")
+	}
+
+	var item C.zmq_pollitem_t
+	C.EventPoller_set_fd(&item, C.int(fd))
+	return p.add(item, PollEvent{Fd: fd, Conn: conn}, events)
+}
+
+// Modify changes the events a socket is polled for. A socket
+// modified to poll for no events stays registered, but is not
+// returned by Wait until it is modified again.
+func (p *EventPoller) Modify(sock *Sock, events int) error {
+	return p.modify(p.index(sock, -1), events)
+}
+
+// ModifyFd changes the events a file descriptor is polled for.
+func (p *EventPoller) ModifyFd(fd int, events int) error {
+	return p.modify(p.index(nil, fd), events)
+}
+
+// ModifyConn changes the events a connection is polled for.
+func (p *EventPoller) ModifyConn(conn syscall.Conn, events int) error {
+	fd, err := connFd(conn)
+	if err != nil {
+		return err
+	}
+	return p.modify(p.index(nil, fd), events)
+}
+
+// Remove removes a socket from the poller.
+func (p *EventPoller) Remove(sock *Sock) error {
+	return p.remove(p.index(sock, -1))
+}
+
+// RemoveFd removes a file descriptor from the poller.
+func (p *EventPoller) RemoveFd(fd int) error {
+	return p.remove(p.index(nil, fd))
+}
+
+// RemoveConn removes a connection from the poller.
+func (p *EventPoller) RemoveConn(conn syscall.Conn) error {
+	fd, err := connFd(conn)
+	if err != nil {
+		return err
+	}
+	return p.remove(p.index(nil, fd))
+}
+
+// Wait waits for the timeout period in milliseconds for any
+// registered item to become ready, and returns every ready item
+// with its ready events. A timeout of -1 waits indefinitely. On
+// timeout it returns an empty slice and a nil error. Like Poller,
+// it returns ErrTerminated if the ZeroMQ context was terminated.
+func (p *EventPoller) Wait(millis int) ([]PollEvent, error) {
+	if p.destroyed {
+		return nil, ErrWaitAfterDestroy
+	}
+	var deadline time.Time
+	if millis > 0 {
+		deadline = time.Now().Add(time.Duration(millis) * time.Millisecond)
+	}
+
+	for {
+		var items *C.zmq_pollitem_t
+		if len(p.items) > 0 {
+			items = &p.items[0]
+		}
+
+		rc, err := C.zmq_poll(items, C.int(len(p.items)), C.long(millis))
+		if rc != -1 {
+			break
+		}
+		if err == syscall.Errno(C.ETERM) {
+			return nil, ErrTerminated
+		}
+		if err != syscall.EINTR {
+			return nil, err
+		}
+
+		// Interrupted by a signal; poll again for the
+		// remainder of the timeout.
+		if millis > 0 {
+			millis = int(time.Until(deadline) / time.Millisecond)
+			if millis <= 0 {
+				return nil, nil
+			}
+		}
+	}
+
+	var ready []PollEvent
+	for i, item := range p.items {
+		if item.revents != 0 {
+			event := p.entries[i]
+			event.Events = int(item.revents)
+			ready = append(ready, event)
+		}
+	}
+	return ready, nil
+}
+
+// Destroy destroys the EventPoller. The sockets it polled
+// are not destroyed.
+func (p *EventPoller) Destroy() {
+	p.items = nil
+	p.entries = nil
+	p.destroyed = true
+}
+
+// add registers a poll item after validating its events
+func (p *EventPoller) add(item C.zmq_pollitem_t, entry PollEvent, events int) error {
+	if p.destroyed {
+		return ErrWaitAfterDestroy
+	}
+	if !validPollEvents(events) {
+		return fmt.Errorf("invalid poll events %d", events)
+	}
+
+	item.events = C.short(events)
+	p.items = append(p.items, item)
+	p.entries = append(p.entries, entry)
+	return nil
+}
+
+// modify changes the events of the poll item at index i
+func (p *EventPoller) modify(i int, events int) error {
+	if i == -1 {
+		return fmt.Errorf("item not in poller")
+	}
+	if events != 0 && !validPollEvents(events) {
+		return fmt.Errorf("invalid poll events %d", events)
+	}
+	p.items[i].events = C.short(events)
+	return nil
+}
+
+// remove removes the poll item at index i
+func (p *EventPoller) remove(i int) error {
+	if i == -1 {
+		return fmt.Errorf("item not in poller")
+	}
+	p.items = append(p.items[:i], p.items[i+1:]...)
+	p.entries = append(p.entries[:i], p.entries[i+1:]...)
+	return nil
+}
+
+// index returns the position of a socket, or of a file
+// descriptor when sock is nil, in the poller, or -1
+func (p *EventPoller) index(sock *Sock, fd int) int {
+	for i, entry := range p.entries {
+		if sock != nil && entry.Sock == sock {
+			return i
+		}
+		if sock == nil && entry.Sock == nil && entry.Fd == fd {
+			return i
+		}
+	}
+	return -1
+}
+
+// validPollEvents reports whether events is a non empty
+// combination of Pollin and Pollout
+func validPollEvents(events int) bool {
+	return events != 0 && events&^(Pollin|Pollout) == 0
+}
+
+// connFd returns the file descriptor underlying conn
+func connFd(conn syscall.Conn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+
+	fd := -1
+	err = raw.Control(func(s uintptr) {
+		fd = int(s)
+	})
+	if err != nil {
+		return -1, err
+	}
+	return fd, nil
+}
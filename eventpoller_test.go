@@ -0,0 +1,169 @@
+package goczmq
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventPoller(t *testing.T) {
+	pull, err := NewPull("inproc://eventpoller")
+	require.NoError(t, err)
+	defer pull.Destroy()
+
+	push, err := NewPush("inproc://eventpoller")
+	require.NoError(t, err)
+	defer push.Destroy()
+
+	poller := NewEventPoller()
+	defer poller.Destroy()
+
+	err = poller.Add(pull, Pollin)
+	require.NoError(t, err)
+
+	err = poller.Add(push, Pollout)
+	require.NoError(t, err)
+
+	ready, err := poller.Wait(100)
+	require.NoError(t, err)
+	if want, have := 1, len(ready); want != have {
+		t.Fatalf("want %#v, have %#v", want, have)
+	}
+	if want, have := push, ready[0].Sock; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := Pollout, ready[0].Events; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	err = push.SendFrame([]byte("Hello"), FlagNone)
+	require.NoError(t, err)
+
+	ready, err = poller.Wait(100)
+	require.NoError(t, err)
+	if want, have := 2, len(ready); want != have {
+		t.Fatalf("want %#v, have %#v", want, have)
+	}
+	if want, have := pull, ready[0].Sock; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := Pollin, ready[0].Events; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	err = poller.Modify(pull, Pollin|Pollout)
+	require.NoError(t, err)
+
+	err = poller.Remove(push)
+	require.NoError(t, err)
+
+	ready, err = poller.Wait(100)
+	require.NoError(t, err)
+	if want, have := 1, len(ready); want != have {
+		t.Fatalf("want %#v, have %#v", want, have)
+	}
+	if want, have := Pollin, ready[0].Events; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	err = poller.Remove(push)
+	require.Error(t, err)
+}
+
+func TestEventPollerTimeout(t *testing.T) {
+	pull, err := NewPull("inproc://eventpollertimeout")
+	require.NoError(t, err)
+	defer pull.Destroy()
+
+	poller := NewEventPoller()
+
+	err = poller.Add(pull, Pollin)
+	require.NoError(t, err)
+
+	err = poller.Add(pull, Pollin)
+	require.Error(t, err)
+
+	ready, err := poller.Wait(10)
+	require.NoError(t, err)
+	if want, have := 0, len(ready); want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	poller.Destroy()
+	_, err = poller.Wait(10)
+	if want, have := ErrWaitAfterDestroy, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+}
+
+func TestEventPollerFdAndConn(t *testing.T) {
+	pull, err := NewPull("inproc://eventpollerfd")
+	require.NoError(t, err)
+	defer pull.Destroy()
+
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer udp.Close()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	poller := NewEventPoller()
+	defer poller.Destroy()
+
+	err = poller.Add(pull, Pollin)
+	require.NoError(t, err)
+
+	err = poller.AddConn(udp, Pollin)
+	require.NoError(t, err)
+
+	err = poller.AddFd(int(r.Fd()), Pollin)
+	require.NoError(t, err)
+
+	ready, err := poller.Wait(10)
+	require.NoError(t, err)
+	if want, have := 0, len(ready); want != have {
+		t.Fatalf("want %#v, have %#v", want, have)
+	}
+
+	client, err := net.DialUDP("udp", nil, udp.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Write([]byte("Hello"))
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("World"))
+	require.NoError(t, err)
+
+	ready, err = poller.Wait(1000)
+	require.NoError(t, err)
+	if want, have := 2, len(ready); want != have {
+		t.Fatalf("want %#v, have %#v", want, have)
+	}
+	if want, have := udp, ready[0].Conn; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if ready[0].Sock != nil {
+		t.Errorf("want nil sock, have %#v", ready[0].Sock)
+	}
+	if want, have := int(r.Fd()), ready[1].Fd; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := Pollin, ready[1].Events; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	err = poller.RemoveConn(udp)
+	require.NoError(t, err)
+
+	err = poller.RemoveFd(int(r.Fd()))
+	require.NoError(t, err)
+
+	err = poller.RemoveFd(int(r.Fd()))
+	require.Error(t, err)
+}
@@ -0,0 +1,355 @@
+package goczmq
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// DefaultChunkSize is the default size of a file transfer chunk
+	DefaultChunkSize = 256 * 1024
+
+	// DefaultCredit is the default number of chunks a
+	// FileReceiver requests ahead
+	DefaultCredit = 10
+)
+
+// castagnoli is the CRC-32C table used for chunk checksums
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// FileSender serves a file in chunks on a Router socket, in the
+// style of the credit based flow control described in the zguide.
+// Receivers request each chunk by offset with a FETCH message, so
+// the sender only reads and sends as fast as they consume, and no
+// message is larger than a chunk. Each chunk carries a CRC-32C
+// checksum of its data.
+//
+// The messages, not counting the Router identity frame, are:
+//
+//	FETCH offset size        receiver to sender
+//	CHUNK offset crc data    sender to receiver
+//	ERROR reason             sender to receiver
+//	DONE                     receiver to sender
+//	ABORT reason             receiver to sender
+//
+// Offsets are 8 byte and sizes and checksums 4 byte big endian
+// integers. A chunk shorter than requested ends the file, DONE
+// ends a complete transfer, and ABORT one the receiver gave up on,
+// such as after a checksum mismatch.
+type FileSender struct {
+	sock          *Sock
+	poller        *Poller
+	maxChunkSize  int
+	timeoutMillis int
+}
+
+// NewFileSender creates a FileSender on a Router socket. The
+// socket will bind by default.
+func NewFileSender(endpoints string, options ...SockOption) (*FileSender, error) {
+	sock, err := NewRouter(endpoints, options...)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	poller, err := NewPoller(sock)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	return &FileSender{
+		sock:          sock,
+		poller:        poller,
+		maxChunkSize:  DefaultChunkSize,
+		timeoutMillis: -1,
+	}, nil
+}
+
+// SetMaxChunkSize sets the largest chunk a receiver may request.
+// It should be below the Maxmsgsize of the receiving socket.
+func (s *FileSender) SetMaxChunkSize(size int) {
+	s.maxChunkSize = size
+}
+
+// SetTimeout sets how long in milliseconds ReadFrom waits for
+// the next request before returning ErrTimeout.
+func (s *FileSender) SetTimeout(ms int) {
+	s.timeoutMillis = ms
+}
+
+// ReadFrom satisfies io.ReaderFrom. It serves the contents of r
+// to receivers until one of them ends the transfer, and returns
+// the number of bytes sent. If a receiver aborts the transfer,
+// ReadFrom returns an error with its reason. If r is an
+// io.ReaderAt, such as an *os.File, chunks can be requested at
+// any offset, which allows receivers to resume; otherwise they
+// must be requested in order.
+func (s *FileSender) ReadFrom(r io.Reader) (int64, error) {
+	readerAt, seekable := r.(io.ReaderAt)
+	var sent int64
+	var pos int64
+
+	for {
+		sock, err := s.poller.Wait(s.timeoutMillis)
+		if err != nil {
+			return sent, err
+		}
+		if sock == nil {
+			return sent, ErrTimeout
+		}
+
+		msg, err := s.sock.RecvMessage()
+		if err != nil {
+			return sent, err
+		}
+		if len(msg) < 2 {
+			continue
+		}
+
+		id := msg[0]
+		switch string(msg[1]) {
+		case "DONE":
+			return sent, nil
+
+		case "ABORT":
+			reason := "no reason given"
+			if len(msg) > 2 {
+				reason = string(msg[2])
+			}
+			return sent, fmt.Errorf("file receiver aborted: %s", reason)
+
+		case "FETCH":
+			if len(msg) != 4 || len(msg[2]) != 8 || len(msg[3]) != 4 {
+				s.sendError(id, "malformed fetch")
+				continue
+			}
+			offset := int64(binary.BigEndian.Uint64(msg[2]))
+			size := int(binary.BigEndian.Uint32(msg[3]))
+			if size == 0 || size > s.maxChunkSize {
+				s.sendError(id, fmt.Sprintf("chunk size %d not in 1..%d", size, s.maxChunkSize))
+				continue
+			}
+
+			buf := make([]byte, size)
+			var n int
+			if seekable {
+				n, err = readerAt.ReadAt(buf, offset)
+			} else {
+				if offset < pos {
+					s.sendError(id, fmt.Sprintf("offset %d is no longer available", offset))
+					continue
+				}
+				if offset > pos {
+					var skipped int64
+					skipped, err = io.CopyN(io.Discard, r, offset-pos)
+					pos += skipped
+				}
+				if err == nil {
+					n, err = io.ReadFull(r, buf)
+					pos += int64(n)
+				}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = nil
+			}
+			if err != nil {
+				s.sendError(id, err.Error())
+				return sent, err
+			}
+
+			err = s.sock.SendMessage(fileChunk(id, offset, buf[:n]))
+			if err != nil {
+				return sent, err
+			}
+			sent += int64(n)
+
+		default:
+			s.sendError(id, fmt.Sprintf("unknown command %q", msg[1]))
+		}
+	}
+}
+
+// sendError sends an ERROR reply to a receiver
+func (s *FileSender) sendError(id []byte, reason string) {
+	s.sock.SendMessage([][]byte{id, []byte("ERROR"), []byte(reason)})
+}
+
+// Destroy destroys the FileSender and its socket
+func (s *FileSender) Destroy() {
+	s.poller.Destroy()
+	s.sock.Destroy()
+}
+
+// fileChunk builds a CHUNK message
+func fileChunk(id []byte, offset int64, data []byte) [][]byte {
+	off := make([]byte, 8)
+	binary.BigEndian.PutUint64(off, uint64(offset))
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.Checksum(data, castagnoli))
+	return [][]byte{id, []byte("CHUNK"), off, crc, data}
+}
+
+// FileReceiver fetches a file from a FileSender over a Dealer
+// socket, keeping up to its credit of chunk requests in flight.
+type FileReceiver struct {
+	sock          *Sock
+	poller        *Poller
+	chunkSize     int
+	credit        int
+	offset        int64
+	timeoutMillis int
+}
+
+// NewFileReceiver creates a FileReceiver on a Dealer socket.
+// The socket will connect by default.
+func NewFileReceiver(endpoints string, options ...SockOption) (*FileReceiver, error) {
+	sock, err := NewDealer(endpoints, options...)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	poller, err := NewPoller(sock)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	return &FileReceiver{
+		sock:          sock,
+		poller:        poller,
+		chunkSize:     DefaultChunkSize,
+		credit:        DefaultCredit,
+		timeoutMillis: -1,
+	}, nil
+}
+
+// SetChunkSize sets the size of the chunks requested
+func (r *FileReceiver) SetChunkSize(size int) {
+	r.chunkSize = size
+}
+
+// SetCredit sets how many chunks are requested ahead
+func (r *FileReceiver) SetCredit(credit int) {
+	r.credit = credit
+}
+
+// SetOffset sets the offset the next WriteTo starts from,
+// to resume an interrupted transfer.
+func (r *FileReceiver) SetOffset(offset int64) {
+	r.offset = offset
+}
+
+// Offset returns the offset following the last byte written.
+// After a failed WriteTo, a new FileReceiver can resume from it.
+func (r *FileReceiver) Offset() int64 {
+	return r.offset
+}
+
+// SetTimeout sets how long in milliseconds WriteTo waits for
+// the next chunk before returning ErrTimeout.
+func (r *FileReceiver) SetTimeout(ms int) {
+	r.timeoutMillis = ms
+}
+
+// WriteTo satisfies io.WriterTo. It fetches the file from the
+// current offset and writes it to w in order, returning the
+// number of bytes written. It returns ErrChecksum if a chunk
+// is corrupted.
+func (r *FileReceiver) WriteTo(w io.Writer) (int64, error) {
+	if r.chunkSize <= 0 || r.credit <= 0 {
+		return 0, fmt.Errorf("invalid chunk size %d or credit %d", r.chunkSize, r.credit)
+	}
+
+	var written int64
+	next := r.offset
+	inFlight := 0
+	eof := false
+
+	for {
+		for !eof && inFlight < r.credit {
+			err := r.fetch(next)
+			if err != nil {
+				return written, err
+			}
+			next += int64(r.chunkSize)
+			inFlight++
+		}
+
+		if inFlight == 0 {
+			return written, r.sock.SendFrame([]byte("DONE"), FlagNone)
+		}
+
+		sock, err := r.poller.Wait(r.timeoutMillis)
+		if err != nil {
+			return written, err
+		}
+		if sock == nil {
+			return written, ErrTimeout
+		}
+
+		msg, err := r.sock.RecvMessage()
+		if err != nil {
+			return written, err
+		}
+		inFlight--
+
+		switch {
+		case len(msg) == 2 && string(msg[0]) == "ERROR":
+			return written, r.abort(fmt.Errorf("file sender: %s", msg[1]))
+
+		case len(msg) == 4 && string(msg[0]) == "CHUNK" && len(msg[1]) == 8 && len(msg[2]) == 4:
+			if eof {
+				// Chunks requested past the end are empty
+				continue
+			}
+
+			offset := int64(binary.BigEndian.Uint64(msg[1]))
+			data := msg[3]
+			if offset != r.offset {
+				return written, r.abort(fmt.Errorf("chunk offset %d, want %d", offset, r.offset))
+			}
+			if crc32.Checksum(data, castagnoli) != binary.BigEndian.Uint32(msg[2]) {
+				return written, r.abort(ErrChecksum)
+			}
+
+			n, err := w.Write(data)
+			written += int64(n)
+			r.offset += int64(n)
+			if err != nil {
+				return written, r.abort(err)
+			}
+			if len(data) < r.chunkSize {
+				eof = true
+			}
+
+		default:
+			return written, r.abort(errors.New("malformed file transfer reply"))
+		}
+	}
+}
+
+// fetch requests the chunk at offset
+func (r *FileReceiver) fetch(offset int64) error {
+	off := make([]byte, 8)
+	binary.BigEndian.PutUint64(off, uint64(offset))
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, uint32(r.chunkSize))
+	return r.sock.SendMessage([][]byte{[]byte("FETCH"), off, size})
+}
+
+// abort ends the transfer on the sender as failed and returns err
+func (r *FileReceiver) abort(err error) error {
+	r.sock.SendMessage([][]byte{[]byte("ABORT"), []byte(err.Error())})
+	return err
+}
+
+// Destroy destroys the FileReceiver and its socket
+func (r *FileReceiver) Destroy() {
+	r.poller.Destroy()
+	r.sock.Destroy()
+}
@@ -0,0 +1,168 @@
+package goczmq
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTransfer(t *testing.T) {
+	sender, err := NewFileSender("inproc://filetransfer")
+	require.NoError(t, err)
+	defer sender.Destroy()
+
+	receiver, err := NewFileReceiver("inproc://filetransfer")
+	require.NoError(t, err)
+	defer receiver.Destroy()
+
+	receiver.SetChunkSize(1000)
+	receiver.SetCredit(4)
+
+	data := make([]byte, 123456)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	done := make(chan error)
+	go func() {
+		_, err := sender.ReadFrom(bytes.NewBuffer(data))
+		done <- err
+	}()
+
+	var out bytes.Buffer
+	n, err := receiver.WriteTo(&out)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	if want, got := int64(len(data)), n; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if !bytes.Equal(data, out.Bytes()) {
+		t.Errorf("received file does not match sent file")
+	}
+	if want, got := int64(len(data)), receiver.Offset(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestFileTransferResume(t *testing.T) {
+	sender, err := NewFileSender("inproc://filetransferresume")
+	require.NoError(t, err)
+	defer sender.Destroy()
+
+	receiver, err := NewFileReceiver("inproc://filetransferresume")
+	require.NoError(t, err)
+	defer receiver.Destroy()
+
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	receiver.SetChunkSize(512)
+	receiver.SetOffset(4000)
+
+	done := make(chan error)
+	go func() {
+		_, err := sender.ReadFrom(bytes.NewReader(data))
+		done <- err
+	}()
+
+	var out bytes.Buffer
+	n, err := receiver.WriteTo(&out)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	if want, got := int64(len(data)-4000), n; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if !bytes.Equal(data[4000:], out.Bytes()) {
+		t.Errorf("received file does not match sent file")
+	}
+}
+
+func TestFileTransferErrors(t *testing.T) {
+	sender, err := NewFileSender("inproc://filetransfererrors")
+	require.NoError(t, err)
+	defer sender.Destroy()
+
+	receiver, err := NewFileReceiver("inproc://filetransfererrors")
+	require.NoError(t, err)
+	defer receiver.Destroy()
+
+	sender.SetMaxChunkSize(100)
+	receiver.SetChunkSize(1000)
+
+	done := make(chan error)
+	go func() {
+		_, err := sender.ReadFrom(bytes.NewReader([]byte("data")))
+		done <- err
+	}()
+
+	_, err = receiver.WriteTo(io.Discard)
+	if err == nil {
+		t.Errorf("expected chunk size error")
+	}
+
+	// The receiver aborts, so the sender reports the
+	// transfer as failed
+	err = <-done
+	if err == nil || !strings.Contains(err.Error(), "chunk size 1000") {
+		t.Errorf("want the receiver's abort reason, got %v", err)
+	}
+
+	sender.SetTimeout(10)
+	_, err = sender.ReadFrom(bytes.NewReader([]byte("data")))
+	assertEqual(t, ErrTimeout, err)
+}
+
+func TestFileTransferChecksum(t *testing.T) {
+	// A fake sender that corrupts the first chunk it sends
+	router, err := NewRouter("inproc://filetransferchecksum")
+	require.NoError(t, err)
+	defer router.Destroy()
+	router.SetOption(SockSetRcvtimeo(2000))
+
+	receiver, err := NewFileReceiver("inproc://filetransferchecksum")
+	require.NoError(t, err)
+	defer receiver.Destroy()
+	receiver.SetTimeout(2000)
+
+	type result struct {
+		n   int64
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		n, err := receiver.WriteTo(io.Discard)
+		done <- result{n, err}
+	}()
+
+	fetch, err := router.RecvMessage()
+	require.NoError(t, err)
+	if want, got := "FETCH", string(fetch[1]); want != got {
+		t.Fatalf("want '%s', got '%s'", want, got)
+	}
+
+	chunk := fileChunk(fetch[0], 0, []byte("data"))
+	chunk[4] = []byte("dama")
+	err = router.SendMessage(chunk)
+	require.NoError(t, err)
+
+	res := <-done
+	assertEqual(t, ErrChecksum, res.err)
+	assertEqual(t, int64(0), res.n)
+
+	// The receiver aborts the transfer with the reason
+	for {
+		msg, err := router.RecvMessage()
+		require.NoError(t, err)
+		if string(msg[1]) == "ABORT" {
+			if want, got := ErrChecksum.Error(), string(msg[2]); want != got {
+				t.Errorf("want '%s', got '%s'", want, got)
+			}
+			break
+		}
+		if want, got := "FETCH", string(msg[1]); want != got {
+			t.Fatalf("want '%s', got '%s'", want, got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package goczmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Framing converts between multipart messages and a byte stream.
+// It is used by a ReadWriter in stream mode, see SetFraming.
+type Framing interface {
+	// Encode appends the stream encoding of msg to dst and
+	// returns the extended buffer.
+	Encode(dst []byte, msg [][]byte) ([]byte, error)
+
+	// Decode decodes the first message in src and returns it
+	// with the number of bytes it used. If src does not yet
+	// hold a complete message, it returns a nil message and 0.
+	Decode(src []byte) ([][]byte, int, error)
+}
+
+// LengthPrefixFraming encodes a message as a 4 byte big endian
+// frame count, followed by each frame as a 4 byte big endian
+// length and the frame data. It can carry any binary message.
+type LengthPrefixFraming struct {
+	// MaxFrameSize limits the size of a decoded frame.
+	// Zero means no limit.
+	MaxFrameSize int
+}
+
+// Encode satisfies Framing
+func (f LengthPrefixFraming) Encode(dst []byte, msg [][]byte) ([]byte, error) {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(msg)))
+	for _, frame := range msg {
+		dst = binary.BigEndian.AppendUint32(dst, uint32(len(frame)))
+		dst = append(dst, frame...)
+	}
+	return dst, nil
+}
+
+// Decode satisfies Framing
+func (f LengthPrefixFraming) Decode(src []byte) ([][]byte, int, error) {
+	if len(src) < 4 {
+		return nil, 0, nil
+	}
+	count := int(binary.BigEndian.Uint32(src))
+	if count == 0 {
+		return nil, 0, fmt.Errorf("invalid frame count %d", count)
+	}
+
+	pos := 4
+	msg := make([][]byte, 0, min(count, 64))
+	for i := 0; i < count; i++ {
+		if len(src)-pos < 4 {
+			return nil, 0, nil
+		}
+		size := int(binary.BigEndian.Uint32(src[pos:]))
+		if f.MaxFrameSize > 0 && size > f.MaxFrameSize {
+			return nil, 0, fmt.Errorf("frame size %d exceeds limit %d", size, f.MaxFrameSize)
+		}
+		pos += 4
+
+		if len(src)-pos < size {
+			return nil, 0, nil
+		}
+		msg = append(msg, append([]byte{}, src[pos:pos+size]...))
+		pos += size
+	}
+	return msg, pos, nil
+}
+
+// DelimiterFraming encodes a message as its frames separated by
+// FrameDelimiter and terminated by MessageDelimiter, for example
+// tab separated frames on newline terminated lines. Frames must
+// not contain either delimiter. If FrameDelimiter is empty, only
+// single frame messages are supported.
+type DelimiterFraming struct {
+	FrameDelimiter   []byte
+	MessageDelimiter []byte
+}
+
+// Encode satisfies Framing
+func (f DelimiterFraming) Encode(dst []byte, msg [][]byte) ([]byte, error) {
+	if len(f.MessageDelimiter) == 0 {
+		return dst, fmt.Errorf("empty message delimiter")
+	}
+	if len(msg) > 1 && len(f.FrameDelimiter) == 0 {
+		return dst, ErrMultiPartUnsupported
+	}
+
+	for i, frame := range msg {
+		if bytes.Contains(frame, f.MessageDelimiter) {
+			return dst, ErrFramingDelimiter
+		}
+		if len(f.FrameDelimiter) > 0 && bytes.Contains(frame, f.FrameDelimiter) {
+			return dst, ErrFramingDelimiter
+		}
+		if i > 0 {
+			dst = append(dst, f.FrameDelimiter...)
+		}
+		dst = append(dst, frame...)
+	}
+	return append(dst, f.MessageDelimiter...), nil
+}
+
+// Decode satisfies Framing
+func (f DelimiterFraming) Decode(src []byte) ([][]byte, int, error) {
+	if len(f.MessageDelimiter) == 0 {
+		return nil, 0, fmt.Errorf("empty message delimiter")
+	}
+
+	end := bytes.Index(src, f.MessageDelimiter)
+	if end == -1 {
+		return nil, 0, nil
+	}
+
+	record := append([]byte{}, src[:end]...)
+	n := end + len(f.MessageDelimiter)
+	if len(f.FrameDelimiter) == 0 {
+		return [][]byte{record}, n, nil
+	}
+	return bytes.Split(record, f.FrameDelimiter), n, nil
+}
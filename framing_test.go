@@ -0,0 +1,86 @@
+package goczmq
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLengthPrefixFraming(t *testing.T) {
+	f := LengthPrefixFraming{}
+	msg := [][]byte{[]byte("id"), {}, []byte("hello\nworld")}
+
+	buf, err := f.Encode(nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(buf); i++ {
+		decoded, n, err := f.Decode(buf[:i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != nil || n != 0 {
+			t.Fatalf("decoded incomplete message of %d bytes", i)
+		}
+	}
+
+	decoded, n, err := f.Decode(append(buf, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := len(buf), n; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if want, got := len(msg), len(decoded); want != got {
+		t.Fatalf("want %d, got %d", want, got)
+	}
+	for i := range msg {
+		if !bytes.Equal(msg[i], decoded[i]) {
+			t.Errorf("want '%s', got '%s'", msg[i], decoded[i])
+		}
+	}
+
+	f.MaxFrameSize = 4
+	_, _, err = f.Decode(buf)
+	if err == nil {
+		t.Errorf("expected frame size limit error")
+	}
+}
+
+func TestDelimiterFraming(t *testing.T) {
+	f := DelimiterFraming{FrameDelimiter: []byte("\t"), MessageDelimiter: []byte("\n")}
+
+	buf, err := f.Encode(nil, [][]byte{[]byte("a"), {}, []byte("b")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "a\t\tb\n", string(buf); want != got {
+		t.Errorf("want '%q', got '%q'", want, got)
+	}
+
+	_, err = f.Encode(nil, [][]byte{[]byte("a\nb")})
+	assertEqual(t, ErrFramingDelimiter, err)
+
+	decoded, n, err := f.Decode([]byte("x\ty\nz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 4, n; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if want, got := "y", string(decoded[1]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	decoded, n, err = f.Decode([]byte("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != nil || n != 0 {
+		t.Errorf("decoded incomplete message")
+	}
+
+	lines := DelimiterFraming{MessageDelimiter: []byte("\n")}
+	_, err = lines.Encode(nil, [][]byte{[]byte("a"), []byte("b")})
+	assertEqual(t, ErrMultiPartUnsupported, err)
+}
@@ -1,6 +1,6 @@
 module github.com/zeromq/goczmq/v4
 
-go 1.21
+go 1.23
 
 require github.com/nofeaturesonlybugs/z85 v1.0.2
 
@@ -106,6 +106,10 @@ var (
 	// a command to an actor
 	ErrActorCmd = errors.New("error sending actor command")
 
+	// ErrEventsDisabled is returned by Channeler.Events when
+	// the Channeler was not created with Events set
+	ErrEventsDisabled = errors.New("channeler events not enabled")
+
 	// ErrSockAttach is returned when an attach call to a socket fails
 	ErrSockAttach = errors.New("error attaching zsock")
 
@@ -149,13 +153,33 @@ var (
 	// accessing the underlying socket pointer when Wait is called
 	ErrWaitAfterDestroy = errors.New("Wait() is invalid on Poller after Destroy() is called.")
 
+	// ErrInterrupted is returned by a Poller when a Wait is
+	// woken by Interrupt
+	ErrInterrupted = errors.New("poller wait interrupted")
+
+	// ErrTerminated is returned by a Poller when a Wait ends
+	// because the ZeroMQ context was terminated
+	ErrTerminated = errors.New("poller wait terminated")
+
 	// ErrMultiPartUnsupported is returned when a function that does
 	// not support multi-part messages encounters a multi-part message
 	ErrMultiPartUnsupported = errors.New("function does not support multi part messages")
 
+	// ErrFramingDelimiter is returned by DelimiterFraming when
+	// a frame contains one of its delimiters
+	ErrFramingDelimiter = errors.New("frame contains framing delimiter")
+
+	// ErrChecksum is returned by a FileReceiver when a chunk
+	// does not match its checksum
+	ErrChecksum = errors.New("chunk checksum mismatch")
+
 	// ErrTimeout is returned when a function that supports timeouts times out
 	ErrTimeout = errors.New("function timed out")
 
+	// ErrUnknownSock is returned when a MultiChanneler is asked
+	// to use a socket name it does not own
+	ErrUnknownSock = errors.New("unknown socket name")
+
 	// ErrCertNotFound is returned when NewCertFromFile tries to
 	// load a file that does not exist.
 	ErrCertNotFound = errors.New("file not found")
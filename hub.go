@@ -0,0 +1,637 @@
+package goczmq
+
+import (
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// hubSendQueue is the number of messages a Hub queues for a
+// socket that cannot send yet before its SendChan blocks
+const hubSendQueue = 64
+
+// hubRecvQueue is the number of received messages a Hub queues
+// for a socket before it stops reading from the socket
+const hubRecvQueue = 64
+
+// hubErrBuffer is the number of errors a Hub buffers for a
+// socket before dropping them
+const hubErrBuffer = 16
+
+// hubFixedCases is the number of select cases the Hub's
+// dispatch goroutine always has before the per socket ones
+const hubFixedCases = 4
+
+// HubSock is a socket owned by a Hub. Messages sent on SendChan
+// are sent on the socket, and messages received from the socket
+// are delivered on RecvChan, which is closed when the socket is
+// removed or the Hub is destroyed. Errors for the socket are
+// delivered on ErrChan; up to hubErrBuffer errors are buffered,
+// and further errors are dropped until ErrChan is drained.
+// A socket never blocks the Hub or its other sockets: up to
+// hubSendQueue messages are queued while the socket is not
+// writable, after which sends on SendChan block until the socket
+// catches up, and up to hubRecvQueue received messages are
+// queued while RecvChan is not read, after which the Hub stops
+// reading from the socket until RecvChan is drained. Queued
+// messages are discarded when the socket is removed.
+type HubSock struct {
+	Name      string
+	SendChan  chan<- [][]byte
+	RecvChan  <-chan [][]byte
+	ErrChan   <-chan error
+	sendChan  chan [][]byte
+	recvChan  chan [][]byte
+	errChan   chan error
+	sock      *Sock
+	queue     [][][]byte
+	recvQueue [][][]byte
+}
+
+// hubOp is an operation queued for the Hub's poller goroutine
+type hubOp struct {
+	kind  string
+	hs    *HubSock
+	spec  ChannelerSock
+	reply chan error
+}
+
+// hubControl updates the set of sockets the Hub's dispatch
+// goroutine selects on
+type hubControl struct {
+	hs     *HubSock
+	remove bool
+}
+
+// hubCase is a per socket select case of the Hub's dispatch
+// goroutine: the socket's SendChan, or its RecvChan while
+// received messages are queued for it
+type hubCase struct {
+	hs   *HubSock
+	recv bool
+}
+
+// Hub owns many sockets and gives each a pair of Go channels for
+// sending and receiving. Unlike Channeler, which runs two
+// goroutines and two inproc sockets per socket, a Hub polls all
+// of its sockets from a single goroutine, and a second goroutine
+// queues sends from every SendChan for it, waking the poller
+// through its internal signalling socket, and delivers the
+// messages it received on every RecvChan. Sockets are created,
+// used and destroyed only by the poller goroutine, and can be
+// added and removed while the Hub is running.
+type Hub struct {
+	poller     *EventPoller
+	wake       *wakeSignal
+	socks      map[*Sock]*HubSock
+	mu         sync.Mutex
+	ops        []hubOp
+	pending    []*HubSock
+	control    chan hubControl
+	resume     chan struct{}
+	deliver    chan struct{}
+	done       chan struct{}
+	dispatched chan struct{}
+	ErrChan    <-chan error
+	errChan    chan<- error
+	destroyed  bool
+}
+
+// NewHub creates a new Hub with no sockets.
+func NewHub() (*Hub, error) {
+	wake, err := newWakeSignal()
+	if err != nil {
+		return nil, err
+	}
+
+	poller := NewEventPoller()
+	err = poller.Add(wake.pull, Pollin)
+	if err != nil {
+		poller.Destroy()
+		wake.destroy()
+		return nil, err
+	}
+
+	errChan := make(chan error)
+	h := &Hub{
+		poller:     poller,
+		wake:       wake,
+		socks:      make(map[*Sock]*HubSock),
+		control:    make(chan hubControl),
+		resume:     make(chan struct{}, 1),
+		deliver:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		dispatched: make(chan struct{}),
+		ErrChan:    errChan,
+		errChan:    errChan,
+	}
+
+	go h.run()
+	go h.dispatch()
+
+	return h, nil
+}
+
+// Add creates a socket described by spec, which binds or
+// connects in the same way as the Channeler constructors, and
+// returns its HubSock.
+func (h *Hub) Add(spec ChannelerSock) (*HubSock, error) {
+	if h.destroyed {
+		return nil, ErrActorCmd
+	}
+
+	sendChan := make(chan [][]byte)
+	recvChan := make(chan [][]byte)
+	errChan := make(chan error, hubErrBuffer)
+	hs := &HubSock{
+		Name:     spec.Name,
+		SendChan: sendChan,
+		RecvChan: recvChan,
+		ErrChan:  errChan,
+		sendChan: sendChan,
+		recvChan: recvChan,
+		errChan:  errChan,
+	}
+
+	err := h.call(hubOp{kind: "add", hs: hs, spec: spec})
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.update(hubControl{hs: hs})
+	if err != nil {
+		return nil, err
+	}
+	return hs, nil
+}
+
+// Remove stops polling a socket and destroys it. Messages
+// must not be sent on its SendChan after Remove is called.
+func (h *Hub) Remove(hs *HubSock) error {
+	if h.destroyed {
+		return ErrActorCmd
+	}
+
+	err := h.update(hubControl{hs: hs, remove: true})
+	if err != nil {
+		return err
+	}
+	return h.call(hubOp{kind: "remove", hs: hs})
+}
+
+// Destroy destroys the Hub and all of its sockets.
+func (h *Hub) Destroy() {
+	if h.destroyed {
+		return
+	}
+	h.destroyed = true
+
+	h.call(hubOp{kind: "destroy"})
+	<-h.done
+	<-h.dispatched
+}
+
+// update passes a change to the set of sockets to the dispatch
+// goroutine. It returns ErrActorCmd if the Hub has stopped.
+func (h *Hub) update(ctl hubControl) error {
+	select {
+	case h.control <- ctl:
+		return nil
+	case <-h.dispatched:
+		return ErrActorCmd
+	}
+}
+
+// call queues an operation for the poller goroutine and
+// waits for its result. It returns ErrActorCmd if the poller
+// goroutine exits without running the operation.
+func (h *Hub) call(op hubOp) error {
+	op.reply = make(chan error, 1)
+
+	h.mu.Lock()
+	h.ops = append(h.ops, op)
+	h.mu.Unlock()
+	h.wake.signal()
+
+	select {
+	case err := <-op.reply:
+		return err
+	case <-h.done:
+		select {
+		case err := <-op.reply:
+			return err
+		default:
+			return ErrActorCmd
+		}
+	}
+}
+
+// send queues a message for a socket, waking the poller
+// goroutine if the queue was empty. It returns true once
+// the queue is full.
+func (h *Hub) send(hs *HubSock, msg [][]byte) bool {
+	h.mu.Lock()
+	hs.queue = append(hs.queue, msg)
+	wake := len(hs.queue) == 1
+	if wake {
+		h.pending = append(h.pending, hs)
+	}
+	full := len(hs.queue) >= hubSendQueue
+	h.mu.Unlock()
+
+	if wake {
+		h.wake.signal()
+	}
+	return full
+}
+
+// full reports whether the send queue of a socket is full
+func (h *Hub) full(hs *HubSock) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(hs.queue) >= hubSendQueue
+}
+
+// recv receives a message from a socket and queues it for
+// delivery on RecvChan, no longer polling the socket for
+// Pollin once the queue is full.
+func (h *Hub) recv(hs *HubSock) {
+	msg, err := recvChannelerMessage(hs.sock)
+	if err != nil {
+		hs.report(err)
+		return
+	}
+
+	h.mu.Lock()
+	hs.recvQueue = append(hs.recvQueue, msg)
+	notify := len(hs.recvQueue) == 1
+	full := len(hs.recvQueue) >= hubRecvQueue
+	h.mu.Unlock()
+
+	if notify {
+		select {
+		case h.deliver <- struct{}{}:
+		default:
+		}
+	}
+	if full {
+		h.poll(hs)
+	}
+}
+
+// delivered drops the message a socket's RecvChan took from
+// its receive queue, and has the poller goroutine poll the
+// socket for Pollin again if the queue was full.
+func (h *Hub) delivered(hs *HubSock) {
+	h.mu.Lock()
+	if len(hs.recvQueue) == 0 {
+		// The socket was destroyed by a shutdown
+		h.mu.Unlock()
+		return
+	}
+	resume := len(hs.recvQueue) >= hubRecvQueue
+	hs.recvQueue[0] = nil
+	hs.recvQueue = hs.recvQueue[1:]
+	if resume {
+		h.pending = append(h.pending, hs)
+	}
+	h.mu.Unlock()
+
+	if resume {
+		h.wake.signal()
+	}
+}
+
+// nextRecv points a RecvChan select case at the next message
+// queued for a socket, or disables it if there is none.
+func (h *Hub) nextRecv(c *reflect.SelectCase, hs *HubSock) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(hs.recvQueue) == 0 {
+		// The zero Value makes reflect.Select
+		// ignore the case
+		c.Chan = reflect.Value{}
+		c.Send = reflect.Value{}
+		return
+	}
+	c.Chan = reflect.ValueOf(hs.recvChan)
+	c.Send = reflect.ValueOf(hs.recvQueue[0])
+}
+
+// report delivers an error for a socket on its ErrChan,
+// dropping it if the buffer is full.
+func (hs *HubSock) report(err error) {
+	select {
+	case hs.errChan <- err:
+	default:
+	}
+}
+
+// run is the poller goroutine that owns the Hub's sockets.
+func (h *Hub) run() {
+	defer close(h.done)
+
+	for {
+		events, err := h.poller.Wait(-1)
+		switch {
+		case err == ErrTerminated:
+			h.shutdown()
+			return
+
+		case err != nil:
+			h.errChan <- err
+			continue
+		}
+
+		for _, event := range events {
+			if event.Sock == h.wake.pull {
+				h.wake.drain()
+				if !h.runOps() {
+					return
+				}
+				continue
+			}
+
+			// The socket may have been removed by an
+			// operation earlier in this batch
+			hs, ok := h.socks[event.Sock]
+			if !ok {
+				continue
+			}
+
+			if event.Events&Pollout != 0 {
+				h.flush(hs)
+			}
+
+			if event.Events&Pollin != 0 {
+				h.recv(hs)
+			}
+		}
+	}
+}
+
+// runOps runs the queued operations and then sends newly
+// queued messages. It returns false once the Hub has been
+// destroyed.
+func (h *Hub) runOps() bool {
+	h.mu.Lock()
+	ops := h.ops
+	h.ops = nil
+	pending := h.pending
+	h.pending = nil
+	h.mu.Unlock()
+
+	for _, op := range ops {
+		switch op.kind {
+		case "add":
+			op.reply <- h.addSock(op.hs, op.spec)
+
+		case "remove":
+			if op.hs.sock == nil || h.socks[op.hs.sock] != op.hs {
+				op.reply <- ErrUnknownSock
+				continue
+			}
+			op.reply <- h.removeSock(op.hs)
+
+		case "destroy":
+			h.shutdown()
+			op.reply <- nil
+			return false
+		}
+	}
+
+	for _, hs := range pending {
+		if hs.sock != nil && h.socks[hs.sock] == hs {
+			h.flush(hs)
+		}
+	}
+	return true
+}
+
+// flush sends the messages queued for a socket until it would
+// block, and polls the socket for Pollout while any remain.
+func (h *Hub) flush(hs *HubSock) {
+	h.mu.Lock()
+	n := len(hs.queue)
+	h.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		h.mu.Lock()
+		msg := hs.queue[0]
+		h.mu.Unlock()
+
+		err := trySendHubMessage(hs.sock, msg)
+		if err == syscall.EAGAIN {
+			break
+		}
+
+		h.mu.Lock()
+		resume := len(hs.queue) >= hubSendQueue
+		hs.queue[0] = nil
+		hs.queue = hs.queue[1:]
+		h.mu.Unlock()
+
+		if resume {
+			select {
+			case h.resume <- struct{}{}:
+			default:
+			}
+		}
+
+		if err != nil {
+			hs.report(err)
+		}
+	}
+
+	h.poll(hs)
+}
+
+// poll sets the events a socket is polled for: Pollin unless
+// its receive queue is full, and Pollout while messages are
+// queued for sending.
+func (h *Hub) poll(hs *HubSock) {
+	h.mu.Lock()
+	events := 0
+	if len(hs.recvQueue) < hubRecvQueue {
+		events |= Pollin
+	}
+	if len(hs.queue) > 0 {
+		events |= Pollout
+	}
+	h.mu.Unlock()
+
+	err := h.poller.Modify(hs.sock, events)
+	if err != nil {
+		hs.report(err)
+	}
+}
+
+// trySendHubMessage sends msg on sock without blocking. It
+// returns syscall.EAGAIN, having sent nothing, if the socket
+// cannot take the message yet.
+func trySendHubMessage(sock *Sock, msg [][]byte) error {
+	if isDraftSock(sock) {
+		// The draft sends have no non blocking form, so
+		// wait until the socket reports it is writable
+		if !sock.Pollout() {
+			return syscall.EAGAIN
+		}
+		return sendChannelerMessage(sock, msg)
+	}
+
+	for i, frame := range msg {
+		flags := FlagDontWait
+		if i < len(msg)-1 {
+			flags |= FlagMore
+		}
+
+		// Only the first frame can fail with EAGAIN, the
+		// rest of a message is always accepted with it
+		err := sock.SendFrame(frame, flags)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSock creates, attaches and starts polling a socket
+func (h *Hub) addSock(hs *HubSock, spec ChannelerSock) error {
+	sock := NewSock(spec.Type, spec.Options...)
+
+	err := attachChannelerSock(sock, spec.Endpoints, spec.Subscribe)
+	if err != nil {
+		sock.Destroy()
+		return err
+	}
+
+	err = h.poller.Add(sock, Pollin)
+	if err != nil {
+		sock.Destroy()
+		return err
+	}
+
+	hs.sock = sock
+	h.socks[sock] = hs
+	return nil
+}
+
+// shutdown destroys every socket and the pollers
+func (h *Hub) shutdown() {
+	for _, hs := range h.socks {
+		h.removeSock(hs)
+	}
+	h.poller.Destroy()
+	h.wake.destroy()
+}
+
+// removeSock stops polling and destroys a socket, discarding
+// any messages still queued for it
+func (h *Hub) removeSock(hs *HubSock) error {
+	err := h.poller.Remove(hs.sock)
+	delete(h.socks, hs.sock)
+	hs.sock.Destroy()
+
+	h.mu.Lock()
+	hs.queue = nil
+	hs.recvQueue = nil
+	h.mu.Unlock()
+	return err
+}
+
+// dispatch queues messages from every SendChan for the poller
+// goroutine, and delivers received messages on every RecvChan.
+// A SendChan is not read while its socket's queue is full, so
+// senders block until the socket catches up. RecvChans are
+// closed by dispatch, when their socket is removed or once the
+// poller goroutine has exited.
+func (h *Hub) dispatch() {
+	defer close(h.dispatched)
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.control)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.resume)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.deliver)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.done)},
+	}
+	entries := make([]hubCase, hubFixedCases)
+
+	for {
+		i, value, ok := reflect.Select(cases)
+		switch i {
+		case 0:
+			ctl := value.Interface().(hubControl)
+			if !ctl.remove {
+				cases = append(cases,
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctl.hs.sendChan)},
+					reflect.SelectCase{Dir: reflect.SelectSend})
+				entries = append(entries, hubCase{hs: ctl.hs}, hubCase{hs: ctl.hs, recv: true})
+				h.nextRecv(&cases[len(cases)-1], ctl.hs)
+				continue
+			}
+
+			found := false
+			for j := len(entries) - 1; j >= hubFixedCases; j-- {
+				if entries[j].hs == ctl.hs {
+					cases = append(cases[:j], cases[j+1:]...)
+					entries = append(entries[:j], entries[j+1:]...)
+					found = true
+				}
+			}
+			if found {
+				close(ctl.hs.recvChan)
+			}
+			continue
+
+		case 1:
+			// A queue has room again; read from every
+			// SendChan whose queue is no longer full
+			for j := hubFixedCases; j < len(cases); j++ {
+				if !entries[j].recv && !cases[j].Chan.IsValid() && !h.full(entries[j].hs) {
+					cases[j].Chan = reflect.ValueOf(entries[j].hs.sendChan)
+				}
+			}
+			continue
+
+		case 2:
+			// Messages were received; deliver them on every
+			// RecvChan that was idle
+			for j := hubFixedCases; j < len(cases); j++ {
+				if entries[j].recv && !cases[j].Chan.IsValid() {
+					h.nextRecv(&cases[j], entries[j].hs)
+				}
+			}
+			continue
+
+		case 3:
+			// The poller goroutine has exited
+			for j := hubFixedCases; j < len(cases); j++ {
+				if entries[j].recv {
+					close(entries[j].hs.recvChan)
+				}
+			}
+			return
+		}
+
+		entry := entries[i]
+		if entry.recv {
+			h.delivered(entry.hs)
+			h.nextRecv(&cases[i], entry.hs)
+			continue
+		}
+
+		if !ok {
+			// SendChan was closed; stop selecting on it
+			cases = append(cases[:i], cases[i+1:]...)
+			entries = append(entries[:i], entries[i+1:]...)
+			continue
+		}
+
+		if h.send(entry.hs, value.Interface().([][]byte)) {
+			// The zero Value makes reflect.Select
+			// ignore the case until it is resumed
+			cases[i].Chan = reflect.Value{}
+		}
+	}
+}
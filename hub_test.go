@@ -0,0 +1,215 @@
+package goczmq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub(t *testing.T) {
+	hub, err := NewHub()
+	require.NoError(t, err)
+	defer hub.Destroy()
+
+	router, err := hub.Add(ChannelerSock{Name: "router", Type: Router, Endpoints: "inproc://hubrouter"})
+	require.NoError(t, err)
+
+	dealer, err := hub.Add(ChannelerSock{Name: "dealer", Type: Dealer, Endpoints: "inproc://hubrouter"})
+	require.NoError(t, err)
+
+	dealer.SendChan <- [][]byte{[]byte("hello")}
+
+	var request [][]byte
+	select {
+	case request = <-router.RecvChan:
+	case err := <-router.ErrChan:
+		t.Fatal(err)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	if want, got := "hello", string(request[1]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	router.SendChan <- [][]byte{request[0], []byte("world")}
+
+	select {
+	case resp := <-dealer.RecvChan:
+		if want, got := "world", string(resp[0]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+	case err := <-dealer.ErrChan:
+		t.Fatal(err)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	err = hub.Remove(dealer)
+	require.NoError(t, err)
+
+	_, ok := <-dealer.RecvChan
+	if ok {
+		t.Errorf("expected RecvChan to be closed")
+	}
+
+	err = hub.Remove(dealer)
+	assertEqual(t, ErrUnknownSock, err)
+
+	_, err = hub.Add(ChannelerSock{Type: Dealer})
+	assertEqual(t, ErrSockAttachEmptyEndpoints, err)
+}
+
+func TestHubDestroyClosesRecvChan(t *testing.T) {
+	hub, err := NewHub()
+	require.NoError(t, err)
+
+	pull, err := hub.Add(ChannelerSock{Type: Pull, Endpoints: "inproc://hubdestroy"})
+	require.NoError(t, err)
+
+	hub.Destroy()
+
+	_, ok := <-pull.RecvChan
+	if ok {
+		t.Errorf("expected RecvChan to be closed")
+	}
+
+	_, err = hub.Add(ChannelerSock{Type: Pull, Endpoints: "inproc://hubdestroy"})
+	assertEqual(t, ErrActorCmd, err)
+}
+
+func TestHubSlowSock(t *testing.T) {
+	hub, err := NewHub()
+	require.NoError(t, err)
+	defer hub.Destroy()
+
+	// Nothing listens on the dealer's endpoint, so it can only
+	// take a message or two before it would block
+	dealer, err := hub.Add(ChannelerSock{Type: Dealer, Endpoints: "tcp://127.0.0.1:31340", Options: []SockOption{SockSetSndhwm(1)}})
+	require.NoError(t, err)
+
+	pull, err := hub.Add(ChannelerSock{Type: Pull, Endpoints: "inproc://hubslowsock"})
+	require.NoError(t, err)
+
+	push, err := NewPush("inproc://hubslowsock")
+	require.NoError(t, err)
+	defer push.Destroy()
+
+	// Sends on the dealer queue up until the queue is full,
+	// and then block the sender rather than the Hub
+	sent, blocked := 0, false
+	for i := 0; i < hubSendQueue+10 && !blocked; i++ {
+		select {
+		case dealer.SendChan <- [][]byte{[]byte("hello")}:
+			sent++
+		case <-time.After(time.Millisecond * 100):
+			blocked = true
+		}
+	}
+	if !blocked {
+		t.Errorf("expected sends on a stalled socket to block")
+	}
+
+	err = push.SendFrame([]byte("world"), FlagNone)
+	require.NoError(t, err)
+
+	select {
+	case msg := <-pull.RecvChan:
+		if want, got := "world", string(msg[0]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+	case err := <-dealer.ErrChan:
+		t.Fatal(err)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	// Once a peer appears the queued messages are sent, and
+	// the blocked sender can send again
+	router, err := NewRouter("tcp://127.0.0.1:31340")
+	require.NoError(t, err)
+	defer router.Destroy()
+
+	select {
+	case dealer.SendChan <- [][]byte{[]byte("hello")}:
+		sent++
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	router.SetOption(SockSetRcvtimeo(2000))
+	for i := 0; i < sent; i++ {
+		msg, err := router.RecvMessage()
+		require.NoError(t, err)
+		if want, got := "hello", string(msg[1]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+	}
+}
+
+func TestHubUndrainedSock(t *testing.T) {
+	hub, err := NewHub()
+	require.NoError(t, err)
+	defer hub.Destroy()
+
+	stalled, err := hub.Add(ChannelerSock{Type: Pull, Endpoints: "inproc://hubundrained1"})
+	require.NoError(t, err)
+
+	pull, err := hub.Add(ChannelerSock{Type: Pull, Endpoints: "inproc://hubundrained2"})
+	require.NoError(t, err)
+
+	push1, err := NewPush("inproc://hubundrained1")
+	require.NoError(t, err)
+	defer push1.Destroy()
+
+	push2, err := NewPush("inproc://hubundrained2")
+	require.NoError(t, err)
+	defer push2.Destroy()
+
+	// Nobody reads the stalled socket's RecvChan, which must
+	// not keep the Hub from serving the other socket
+	for i := 0; i < hubRecvQueue+10; i++ {
+		err = push1.SendFrame([]byte("stalled"), FlagNone)
+		require.NoError(t, err)
+	}
+
+	err = push2.SendFrame([]byte("hello"), FlagNone)
+	require.NoError(t, err)
+
+	select {
+	case msg := <-pull.RecvChan:
+		if want, got := "hello", string(msg[0]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	// The stalled socket is read again once it is drained
+	for i := 0; i < hubRecvQueue+10; i++ {
+		select {
+		case msg := <-stalled.RecvChan:
+			if want, got := "stalled", string(msg[0]); want != got {
+				t.Errorf("want '%s', got '%s'", want, got)
+			}
+		case <-time.After(time.Second * 2):
+			t.Fatal("timeout")
+		}
+	}
+
+	for i := 0; i < hubRecvQueue+10; i++ {
+		err = push1.SendFrame([]byte("stalled"), FlagNone)
+		require.NoError(t, err)
+	}
+
+	removed := make(chan error, 1)
+	go func() { removed <- hub.Remove(stalled) }()
+
+	select {
+	case err := <-removed:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 2):
+		t.Fatal("Remove blocked on an undrained RecvChan")
+	}
+}
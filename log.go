@@ -0,0 +1,187 @@
+package goczmq
+
+/*
+#include "czmq.h"
+#ifdef _WIN32
+#include <fcntl.h>
+#include <io.h>
+#endif
+
+// Log_open_pipe creates the pipe czmq writes log lines to. It
+// returns the read end, or -1, and sets *stream to the write end.
+int Log_open_pipe(FILE **stream) {
+	int fds[2];
+#ifdef _WIN32
+	if (_pipe(fds, 4096, _O_BINARY) != 0)
+		return -1;
+#else
+	if (pipe(fds) != 0)
+		return -1;
+#endif
+	*stream = fdopen(fds[1], "w");
+	if (*stream == NULL) {
+		close(fds[0]);
+		close(fds[1]);
+		return -1;
+	}
+	return fds[0];
+}
+
+int Log_read(int fd, void *buf, int size) {
+	return read(fd, buf, size);
+}
+
+// Log_default_stream returns the stream czmq logs to by default,
+// as selected by the ZSYS_LOGSTREAM environment variable.
+FILE *Log_default_stream(void) {
+	const char *name = getenv("ZSYS_LOGSTREAM");
+	if (name == NULL || streq(name, "stdout"))
+		return stdout;
+	if (streq(name, "stderr"))
+		return stderr;
+	return NULL;
+}
+*/
+import "C"
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+var (
+	logMu sync.Mutex
+
+	// logStream is the write end of the log pipe. It is opened
+	// by the first call to SetLogger and never closed, as czmq
+	// threads may still be writing to it after the stream is
+	// switched back.
+	logStream *C.FILE
+
+	// logTarget is the logger lines are forwarded to, or nil
+	logTarget atomic.Pointer[slog.Logger]
+)
+
+// SetLogger routes the log messages czmq writes through zsys,
+// including the output of the Verbose methods of Auth, Beacon,
+// Monitor and Proxy, to logger instead of stdout. Each line is
+// logged at the level czmq gave it, with notices logged at info
+// level, and the name of the actor that wrote it, such as "zauth",
+// is attached as the "actor" attribute. Lines written after
+// SetLogger returns are forwarded. Passing nil stops forwarding
+// and restores the stream czmq logged to before, stdout unless
+// the ZSYS_LOGSTREAM environment variable selects another.
+//
+// czmq writes to a pipe read by a goroutine, which are created
+// by the first call and kept for the life of the process.
+func SetLogger(logger *slog.Logger) error {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if logger == nil {
+		if logTarget.Load() != nil {
+			C.zsys_set_logstream(C.Log_default_stream())
+			logTarget.Store(nil)
+		}
+		return nil
+	}
+
+	if logStream == nil {
+		var stream *C.FILE
+		fd, err := C.Log_open_pipe(&stream)
+		if fd == -1 {
+			return err
+		}
+		logStream = stream
+		go forwardLogs(logPipe(fd))
+	}
+
+	logTarget.Store(logger)
+	C.zsys_set_logstream(logStream)
+	return nil
+}
+
+// logPipe is the read end of the log pipe
+type logPipe C.int
+
+// Read satisfies io.Reader
+func (p logPipe) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	for {
+		n, err := C.Log_read(C.int(p), unsafe.Pointer(&b[0]), C.int(len(b)))
+		switch {
+		case n > 0:
+			return int(n), nil
+		case n == 0:
+			return 0, io.EOF
+		case !isRetryableError(err):
+			return 0, err
+		}
+	}
+}
+
+// forwardLogs passes each line read from the log pipe to the
+// current logger
+func forwardLogs(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		logger := logTarget.Load()
+		if logger == nil {
+			// Written before the stream was switched back
+			continue
+		}
+
+		level, actor, text := parseLogLine(scanner.Text())
+		if actor != "" {
+			logger.Log(context.Background(), level, text, slog.String("actor", actor))
+		} else {
+			logger.Log(context.Background(), level, text)
+		}
+	}
+}
+
+// parseLogLine parses a czmq log line, which has the form
+//
+//	L: (ident) yy-mm-dd hh:mm:ss actor: message
+//
+// where the ident and actor are optional.
+func parseLogLine(line string) (slog.Level, string, string) {
+	level := slog.LevelInfo
+	if len(line) > 3 && line[1] == ':' && line[2] == ' ' {
+		switch line[0] {
+		case 'E':
+			level = slog.LevelError
+		case 'W':
+			level = slog.LevelWarn
+		case 'D':
+			level = slog.LevelDebug
+		}
+		line = line[3:]
+	}
+
+	if strings.HasPrefix(line, "(") {
+		if i := strings.Index(line, ") "); i != -1 {
+			line = line[i+2:]
+		}
+	}
+
+	// Skip the date and time
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) == 3 && len(fields[0]) == 8 && len(fields[1]) == 8 && fields[1][2] == ':' {
+		line = fields[2]
+	}
+
+	if i := strings.Index(line, ": "); i > 0 && !strings.ContainsAny(line[:i], " \t") {
+		return level, line[:i], line[i+2:]
+	}
+	return level, "", line
+}
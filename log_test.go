@@ -0,0 +1,98 @@
+package goczmq
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordHandler is a slog.Handler that sends each record on
+// a channel, dropping records when it is full
+type recordHandler chan slog.Record
+
+func (h recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h recordHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h recordHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h recordHandler) Handle(ctx context.Context, r slog.Record) error {
+	select {
+	case h <- r:
+	default:
+	}
+	return nil
+}
+
+func TestParseLogLine(t *testing.T) {
+	level, actor, msg := parseLogLine("I: 26-10-19 12:00:00 zauth: API command=VERBOSE")
+	assertEqual(t, slog.LevelInfo, level)
+	assertEqual(t, "zauth", actor)
+	assertEqual(t, "API command=VERBOSE", msg)
+
+	level, actor, msg = parseLogLine("E: (myapp) 26-10-19 12:00:00 zbeacon: interface=lo address=127.0.0.1")
+	assertEqual(t, slog.LevelError, level)
+	assertEqual(t, "zbeacon", actor)
+	assertEqual(t, "interface=lo address=127.0.0.1", msg)
+
+	level, actor, msg = parseLogLine("W: 26-10-19 12:00:00 no actor here")
+	assertEqual(t, slog.LevelWarn, level)
+	assertEqual(t, "", actor)
+	assertEqual(t, "no actor here", msg)
+
+	level, _, _ = parseLogLine("D: 26-10-19 12:00:00 zproxy: API command=PAUSE")
+	assertEqual(t, slog.LevelDebug, level)
+}
+
+// waitForRecord waits for a record with the given actor and
+// message, and returns its level
+func waitForRecord(t *testing.T, records recordHandler, actor, msg string) slog.Level {
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case r := <-records:
+			var got string
+			r.Attrs(func(a slog.Attr) bool {
+				if a.Key == "actor" {
+					got = a.Value.String()
+				}
+				return true
+			})
+			if got == actor && r.Message == msg {
+				return r.Level
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for %s log record %q", actor, msg)
+		}
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	records := make(recordHandler, 100)
+	err := SetLogger(slog.New(records))
+	require.NoError(t, err)
+	defer SetLogger(nil)
+
+	auth := NewAuth()
+	defer auth.Destroy()
+
+	err = auth.Verbose()
+	require.NoError(t, err)
+
+	err = auth.Allow("127.0.0.1")
+	require.NoError(t, err)
+	assertEqual(t, slog.LevelInfo, waitForRecord(t, records, "zauth", "API command=ALLOW"))
+
+	// Stopping and starting again reuses the log pipe
+	err = SetLogger(nil)
+	require.NoError(t, err)
+
+	replaced := make(recordHandler, 100)
+	err = SetLogger(slog.New(replaced))
+	require.NoError(t, err)
+
+	err = auth.Deny("127.0.0.2")
+	require.NoError(t, err)
+	waitForRecord(t, replaced, "zauth", "API command=DENY")
+}
@@ -0,0 +1,344 @@
+package goczmq
+
+/*
+#include "czmq.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ChannelerSock describes one of the sockets owned by a
+// MultiChanneler or Hub, or the socket of a Channeler created
+// with NewChanneler. Sockets bind or connect by default in the
+// same way as the single socket Channeler constructors.
+type ChannelerSock struct {
+	Name      string
+	Type      int
+	Endpoints string
+	Subscribe []string
+	Options   []SockOption
+
+	// Events has a Channeler created with NewChanneler monitor
+	// its socket, see Channeler.Events. MultiChanneler and Hub
+	// ignore it.
+	Events bool
+}
+
+// ChannelerMessage is a message sent or received through a
+// MultiChanneler. Sock is the name of the socket the message
+// was received from, or should be sent to.
+type ChannelerMessage struct {
+	Sock   string
+	Frames [][]byte
+}
+
+// MultiChanneler serializes access to several sockets through
+// a single pair of send and receive channels. All sockets are
+// owned by one actor goroutine polling them with one Poller,
+// so traffic from, for example, a Sub feed and a Router control
+// port can be merged without running a Channeler per socket.
+type MultiChanneler struct {
+	id          string
+	socks       []ChannelerSock
+	commandAddr string
+	proxyAddr   string
+	commandChan chan<- channelerRequest
+	optionChan  chan SockOption
+	SendChan    chan<- ChannelerMessage
+	RecvChan    <-chan ChannelerMessage
+	ErrChan     <-chan error
+	errChan     chan<- error
+	done        chan struct{}
+	exited      chan struct{}
+	destroyed   bool
+}
+
+// Destroy sends a message to the MultiChanneler to shut it
+// down and clean it up.
+func (c *MultiChanneler) Destroy() {
+	if c.destroyed {
+		return
+	}
+	select {
+	case c.commandChan <- channelerRequest{cmd: [][]byte{[]byte("destroy")}}:
+	case <-c.done:
+	}
+}
+
+// Subscribe subscribes the named socket to a topic
+func (c *MultiChanneler) Subscribe(name string, topic string) error {
+	return c.command(name, []byte("subscribe"), []byte(topic))
+}
+
+// Unsubscribe unsubscribes the named socket from a topic
+func (c *MultiChanneler) Unsubscribe(name string, topic string) error {
+	return c.command(name, []byte("unsubscribe"), []byte(topic))
+}
+
+// Connect connects the named socket to an additional endpoint.
+func (c *MultiChanneler) Connect(name string, endpoint string) error {
+	return c.command(name, []byte("connect"), []byte(endpoint))
+}
+
+// Disconnect disconnects the named socket from an endpoint.
+func (c *MultiChanneler) Disconnect(name string, endpoint string) error {
+	return c.command(name, []byte("disconnect"), []byte(endpoint))
+}
+
+// Bind binds the named socket to an additional endpoint. On
+// success it returns the port number used for tcp transports,
+// or 0 for other transports.
+func (c *MultiChanneler) Bind(name string, endpoint string) (int, error) {
+	if c.destroyed {
+		return -1, ErrActorCmd
+	}
+	reply := c.request([][]byte{[]byte("bind"), []byte(name), []byte(endpoint)}, nil)
+	if err := channelerReplyError(reply); err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(string(reply[1]))
+}
+
+// Unbind unbinds the named socket from an endpoint.
+func (c *MultiChanneler) Unbind(name string, endpoint string) error {
+	return c.command(name, []byte("unbind"), []byte(endpoint))
+}
+
+// SetOption sets an option on the named socket. The option
+// is applied on the socket thread, and SetOption returns once it
+// has been. A SockOption does not report whether ZeroMQ accepted
+// the value, so the only errors returned are ErrUnknownSock and
+// ErrActorCmd if the MultiChanneler has stopped.
+func (c *MultiChanneler) SetOption(name string, o SockOption) error {
+	if c.destroyed || o == nil {
+		return ErrActorCmd
+	}
+	return channelerReplyError(c.request([][]byte{[]byte("setoption"), []byte(name)}, o))
+}
+
+// command sends a command for the named socket to the actor
+// and waits for the result.
+func (c *MultiChanneler) command(name string, verb []byte, args ...[]byte) error {
+	if c.destroyed {
+		return ErrActorCmd
+	}
+	return channelerReplyError(c.request(append([][]byte{verb, []byte(name)}, args...), nil))
+}
+
+// request sends a command to the actor and returns its reply,
+// or nil if the actor has exited.
+func (c *MultiChanneler) request(cmd [][]byte, option SockOption) [][]byte {
+	return sendChannelerRequest(c.commandChan, c.done, cmd, option)
+}
+
+// actor is a routine that handles communication with
+// the zeromq sockets.
+func (c *MultiChanneler) actor(recvChan chan<- ChannelerMessage) {
+	defer close(c.done)
+
+	pipe, err := NewPair(fmt.Sprintf(">%s", c.commandAddr))
+	if err != nil {
+		c.errChan <- err
+		return
+	}
+	defer pipe.Destroy()
+	defer close(recvChan)
+
+	pull, err := NewPull(c.proxyAddr)
+	if err != nil {
+		c.errChan <- err
+		return
+	}
+	defer pull.Destroy()
+
+	poller, err := NewPoller(pull, pipe)
+	if err != nil {
+		c.errChan <- err
+		return
+	}
+	defer poller.Destroy()
+
+	byName := make(map[string]*Sock, len(c.socks))
+	names := make(map[*Sock]string, len(c.socks))
+	defer func() {
+		for sock := range names {
+			sock.Destroy()
+		}
+	}()
+
+	for _, spec := range c.socks {
+		if _, ok := byName[spec.Name]; ok {
+			c.errChan <- fmt.Errorf("duplicate socket name %q", spec.Name)
+			return
+		}
+
+		sock := NewSock(spec.Type, spec.Options...)
+		byName[spec.Name] = sock
+		names[sock] = spec.Name
+
+		err = attachChannelerSock(sock, spec.Endpoints, spec.Subscribe)
+		if err != nil {
+			c.errChan <- err
+			return
+		}
+
+		err = poller.Add(sock)
+		if err != nil {
+			c.errChan <- err
+			return
+		}
+	}
+
+	for {
+		s, err := poller.Wait(-1)
+		if err == ErrTerminated {
+			return
+		}
+		if err != nil {
+			c.errChan <- err
+			continue
+		}
+		switch s {
+		case nil:
+			continue
+
+		case pipe:
+			cmd, err := pipe.RecvMessage()
+			if err != nil {
+				c.errChan <- err
+				return
+			}
+
+			if string(cmd[0]) == "destroy" {
+				pipe.SendMessage(channelerReply(nil))
+				return
+			}
+
+			if len(cmd) < 2 {
+				pipe.SendMessage(channelerReply(ErrActorCmd))
+				continue
+			}
+			sock, ok := byName[string(cmd[1])]
+			if !ok {
+				if string(cmd[0]) == "setoption" {
+					// Discard the option SetOption queued
+					<-c.optionChan
+				}
+				pipe.SendMessage(channelerReply(ErrUnknownSock))
+				continue
+			}
+			pipe.SendMessage(execChannelerCommand(sock, append([][]byte{cmd[0]}, cmd[2:]...), c.optionChan))
+
+		case pull:
+			msg, err := pull.RecvMessage()
+			if err != nil {
+				c.errChan <- err
+				continue
+			}
+
+			sock, ok := byName[string(msg[0])]
+			if !ok {
+				c.errChan <- ErrUnknownSock
+				continue
+			}
+
+			err = sendChannelerMessage(sock, msg[1:])
+			if err != nil {
+				c.errChan <- err
+				continue
+			}
+
+		default:
+			msg, err := recvChannelerMessage(s)
+			if err != nil {
+				c.errChan <- err
+				continue
+			}
+			recvChan <- ChannelerMessage{Sock: names[s], Frames: msg}
+		}
+	}
+}
+
+// channeler is a routine that handles the channel select loop
+// and sends commands to the zeromq sockets.
+func (c *MultiChanneler) channeler(commandChan <-chan channelerRequest, sendChan <-chan ChannelerMessage) {
+	defer close(c.exited)
+
+	push, err := NewPush(c.proxyAddr)
+	if err != nil {
+		c.errChan <- err
+		return
+	}
+	defer push.Destroy()
+
+	pipe, poller, err := newChannelerPipe(c.commandAddr)
+	if err != nil {
+		c.errChan <- err
+		return
+	}
+	defer pipe.Destroy()
+	defer poller.Destroy()
+
+	for {
+		select {
+		case req := <-commandChan:
+			if string(req.cmd[0]) == "destroy" {
+				c.destroyed = true
+				err = sendChannelerPipe(pipe, c.done, req.cmd)
+				if err == nil {
+					_, err = recvChannelerPipe(pipe, poller, c.done)
+				}
+				if err != nil && err != ErrActorCmd {
+					c.errChan <- err
+				}
+				return
+			}
+
+			if !forwardChannelerRequest(pipe, poller, c.optionChan, c.done, c.errChan, req) {
+				return
+			}
+
+		case msg := <-sendChan:
+			err := push.SendMessage(append([][]byte{[]byte(msg.Sock)}, msg.Frames...))
+			if err != nil {
+				c.errChan <- err
+			}
+
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// NewMultiChanneler creates a new MultiChanneler owning one
+// socket per ChannelerSock. Socket names must be unique.
+func NewMultiChanneler(socks ...ChannelerSock) *MultiChanneler {
+	commandChan := make(chan channelerRequest)
+	sendChan := make(chan ChannelerMessage)
+	recvChan := make(chan ChannelerMessage)
+	errChan := make(chan error)
+
+	C.zsys_init()
+	c := &MultiChanneler{
+		id:          C.GoString(C.zuuid_str(C.zuuid_new())),
+		socks:       socks,
+		commandChan: commandChan,
+		optionChan:  make(chan SockOption, 1),
+		SendChan:    sendChan,
+		RecvChan:    recvChan,
+		ErrChan:     errChan,
+		errChan:     errChan,
+		done:        make(chan struct{}),
+		exited:      make(chan struct{}),
+	}
+	c.commandAddr = fmt.Sprintf("inproc://actorcontrol_%s", c.id)
+	c.proxyAddr = fmt.Sprintf("inproc://proxy_%s", c.id)
+
+	go c.channeler(commandChan, sendChan)
+	go c.actor(recvChan)
+
+	return c
+}
@@ -0,0 +1,105 @@
+package goczmq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMultiChanneler(t *testing.T) {
+	pub := NewPubChanneler("inproc://multichannelerpub")
+	defer pub.Destroy()
+
+	dealer := NewDealerChanneler("inproc://multichannelerrouter")
+	defer dealer.Destroy()
+
+	multi := NewMultiChanneler(
+		ChannelerSock{Name: "feed", Type: Sub, Endpoints: "inproc://multichannelerpub", Subscribe: []string{"a"}},
+		ChannelerSock{Name: "control", Type: Router, Endpoints: "inproc://multichannelerrouter"},
+	)
+	defer multi.Destroy()
+
+	dealer.SendChan <- [][]byte{[]byte("hello")}
+
+	var request ChannelerMessage
+	select {
+	case request = <-multi.RecvChan:
+	case err := <-multi.ErrChan:
+		t.Fatal(err)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	if want, got := "control", request.Sock; want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+	if want, got := "hello", string(request.Frames[1]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	multi.SendChan <- ChannelerMessage{
+		Sock:   "control",
+		Frames: [][]byte{request.Frames[0], []byte("world")},
+	}
+
+	select {
+	case resp := <-dealer.RecvChan:
+		if want, got := "world", string(resp[0]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+
+	err := multi.Subscribe("feed", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = multi.Subscribe("missing", "b")
+	assertEqual(t, ErrUnknownSock, err)
+
+	// A failed SetOption must not leave its option queued
+	for i := 0; i < 2; i++ {
+		err = multi.SetOption("missing", SockSetRcvhwm(10))
+		assertEqual(t, ErrUnknownSock, err)
+	}
+	err = multi.SetOption("feed", SockSetRcvhwm(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(time.Second * 2)
+	for {
+		pub.SendChan <- [][]byte{[]byte("b"), []byte("update")}
+
+		select {
+		case resp := <-multi.RecvChan:
+			if want, got := "feed", resp.Sock; want != got {
+				t.Errorf("want '%s', got '%s'", want, got)
+			}
+			if want, got := "update", string(resp.Frames[1]); want != got {
+				t.Errorf("want '%s', got '%s'", want, got)
+			}
+			return
+		case <-time.After(time.Millisecond * 10):
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+}
+
+func TestMultiChannelerDuplicateName(t *testing.T) {
+	multi := NewMultiChanneler(
+		ChannelerSock{Name: "a", Type: Pull, Endpoints: "inproc://multichannelerdup1"},
+		ChannelerSock{Name: "a", Type: Pull, Endpoints: "inproc://multichannelerdup2"},
+	)
+
+	select {
+	case err := <-multi.ErrChan:
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	case <-time.After(time.Second * 2):
+		t.Errorf("timeout")
+	}
+}
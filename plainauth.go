@@ -0,0 +1,238 @@
+package goczmq
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultPasswordIterations is the PBKDF2 iteration count used
+// by HashPassword
+const DefaultPasswordIterations = 600000
+
+// maxPasswordIterations bounds the iteration count of a parsed
+// hash, so a bad hash file cannot make each login arbitrarily slow
+const maxPasswordIterations = 10000000
+
+// passwordHashPrefix starts every encoded password hash
+const passwordHashPrefix = "$pbkdf2-sha256$"
+
+// HashPassword returns a salted PBKDF2-HMAC-SHA256 hash of
+// password, encoded as
+//
+//	$pbkdf2-sha256$iterations$salt$key
+//
+// with the salt and key in unpadded base64. The result can be
+// passed to PlainAuthenticator.SetHash or stored in a hash file.
+func HashPassword(password string) (string, error) {
+	return hashPassword(password, DefaultPasswordIterations)
+}
+
+// hashPassword hashes password with a new random salt
+func hashPassword(password string, iterations int) (string, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := pbkdf2SHA256([]byte(password), salt, iterations, sha256.Size)
+	return fmt.Sprintf("%s%d$%s$%s", passwordHashPrefix, iterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// passwordHash is a decoded password hash
+type passwordHash struct {
+	iterations int
+	salt       []byte
+	key        []byte
+}
+
+// parsePasswordHash decodes a hash made by HashPassword
+func parsePasswordHash(encoded string) (*passwordHash, error) {
+	fields := strings.Split(strings.TrimPrefix(encoded, passwordHashPrefix), "$")
+	if !strings.HasPrefix(encoded, passwordHashPrefix) || len(fields) != 3 {
+		return nil, fmt.Errorf("invalid password hash")
+	}
+
+	iterations, err := strconv.Atoi(fields[0])
+	if err != nil || iterations < 1 || iterations > maxPasswordIterations {
+		return nil, fmt.Errorf("invalid password hash iterations %q", fields[0])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid password hash salt: %s", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(fields[2])
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("invalid password hash key")
+	}
+
+	return &passwordHash{iterations: iterations, salt: salt, key: key}, nil
+}
+
+// verify reports whether password matches the hash
+func (h *passwordHash) verify(password string) bool {
+	key := pbkdf2SHA256([]byte(password), h.salt, h.iterations, len(h.key))
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+// pbkdf2SHA256 derives a key of keyLen bytes as in RFC 8018
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	u := make([]byte, 0, sha256.Size)
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(nil, block))
+		u = prf.Sum(u[:0])
+
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// PlainAuthenticator is an Authenticator for PLAIN clients that
+// checks passwords against salted hashes kept in memory. Unlike
+// Auth.Plain, it never stores passwords in cleartext. Credentials
+// can be set and removed while the handler is running. A client's
+// User-Id is its username.
+//
+// Checking a password costs as much CPU as hashing it, which is
+// a large fraction of a second with DefaultPasswordIterations,
+// and a ZapHandler checks one request at a time, so a flood of
+// PLAIN handshakes delays every other client. Wrap it in a
+// RateLimiter to refuse repeat offenders without hashing.
+// Unknown usernames are checked against a dummy hash with the
+// highest iteration count of any hash set, or
+// DefaultPasswordIterations before one is set, so they take as
+// long to refuse as a wrong password. Give every user the same
+// cost, or unknown users take as long as the most expensive one.
+type PlainAuthenticator struct {
+	mu     sync.Mutex
+	hashes map[string]*passwordHash
+	dummy  *passwordHash
+}
+
+// NewPlainAuthenticator creates a PlainAuthenticator with no users
+func NewPlainAuthenticator() *PlainAuthenticator {
+	return &PlainAuthenticator{
+		hashes: make(map[string]*passwordHash),
+		dummy: &passwordHash{
+			iterations: DefaultPasswordIterations,
+			salt:       make([]byte, 16),
+			key:        make([]byte, sha256.Size),
+		},
+	}
+}
+
+// SetPassword hashes password with HashPassword and sets it
+// as the user's credential.
+func (p *PlainAuthenticator) SetPassword(username, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return p.SetHash(username, hash)
+}
+
+// SetHash sets a hash made by HashPassword as the user's credential
+func (p *PlainAuthenticator) SetHash(username, hash string) error {
+	h, err := parsePasswordHash(hash)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.hashes) == 0 || h.iterations > p.dummy.iterations {
+		// The dummy costs as much as the most expensive hash,
+		// whatever order users are set in
+		p.dummy = &passwordHash{
+			iterations: h.iterations,
+			salt:       make([]byte, 16),
+			key:        make([]byte, sha256.Size),
+		}
+	}
+	p.hashes[username] = h
+	return nil
+}
+
+// Remove removes a user
+func (p *PlainAuthenticator) Remove(username string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.hashes, username)
+}
+
+// LoadHashes sets the credentials in a hash file, which has one
+// username=hash line per user in the style of the Auth.Plain
+// passwords file. Blank lines and lines starting with # are
+// ignored. Users already set are kept unless the file sets them.
+func (p *PlainAuthenticator) LoadHashes(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		username, hash, ok := strings.Cut(text, "=")
+		if !ok || username == "" {
+			return fmt.Errorf("%s:%d: expected username=hash", filename, line)
+		}
+		err = p.SetHash(username, hash)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %s", filename, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Authenticate satisfies Authenticator
+func (p *PlainAuthenticator) Authenticate(req *ZapRequest) ZapResponse {
+	if req.Mechanism != "PLAIN" || len(req.Credentials) != 2 {
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Invalid mechanism"}
+	}
+	username := string(req.Credentials[0])
+
+	p.mu.Lock()
+	h, ok := p.hashes[username]
+	if !ok {
+		h = p.dummy
+	}
+	p.mu.Unlock()
+
+	if !h.verify(string(req.Credentials[1])) || !ok {
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Invalid username or password"}
+	}
+	return ZapResponse{StatusCode: ZapStatusSuccess, StatusText: "OK", UserID: username}
+}
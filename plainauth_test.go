@@ -0,0 +1,159 @@
+package goczmq
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// Test vectors from RFC 7914 section 11
+	key := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+	assertEqual(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783", hex.EncodeToString(key))
+
+	key = pbkdf2SHA256([]byte("Password"), []byte("NaCl"), 80000, 64)
+	assertEqual(t, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d", hex.EncodeToString(key))
+}
+
+func TestPlainAuthenticator(t *testing.T) {
+	auth := NewPlainAuthenticator()
+
+	hash, err := HashPassword("Password")
+	require.NoError(t, err)
+	if !strings.HasPrefix(hash, "$pbkdf2-sha256$600000$") {
+		t.Errorf("unexpected hash format %s", hash)
+	}
+
+	err = auth.SetHash("admin", hash)
+	require.NoError(t, err)
+
+	good := &ZapRequest{Mechanism: "PLAIN", Credentials: [][]byte{[]byte("admin"), []byte("Password")}}
+	resp := auth.Authenticate(good)
+	assertEqual(t, ZapStatusSuccess, resp.StatusCode)
+	assertEqual(t, "admin", resp.UserID)
+
+	bad := &ZapRequest{Mechanism: "PLAIN", Credentials: [][]byte{[]byte("admin"), []byte("BadPassword")}}
+	assertEqual(t, ZapStatusFailure, auth.Authenticate(bad).StatusCode)
+
+	unknown := &ZapRequest{Mechanism: "PLAIN", Credentials: [][]byte{[]byte("guest"), []byte("Password")}}
+	assertEqual(t, ZapStatusFailure, auth.Authenticate(unknown).StatusCode)
+
+	auth.Remove("admin")
+	assertEqual(t, ZapStatusFailure, auth.Authenticate(good).StatusCode)
+
+	err = auth.SetHash("admin", "Password")
+	if err == nil {
+		t.Errorf("expected error for cleartext password")
+	}
+
+	err = auth.SetHash("admin", "$pbkdf2-sha256$2000000000$c2FsdA$a2V5")
+	if err == nil {
+		t.Errorf("expected error for excessive iterations")
+	}
+}
+
+func TestPlainAuthenticatorUnknownUserTiming(t *testing.T) {
+	auth := NewPlainAuthenticator()
+
+	slowHash, err := hashPassword("Password", 200000)
+	require.NoError(t, err)
+	err = auth.SetHash("slow", slowHash)
+	require.NoError(t, err)
+
+	// Setting a cheaper hash last must not make unknown
+	// users cheaper to refuse than the slow user
+	fastHash, err := hashPassword("Password", 1000)
+	require.NoError(t, err)
+	err = auth.SetHash("fast", fastHash)
+	require.NoError(t, err)
+
+	elapsed := func(username string) time.Duration {
+		req := &ZapRequest{Mechanism: "PLAIN", Credentials: [][]byte{[]byte(username), []byte("BadPassword")}}
+		start := time.Now()
+		assertEqual(t, ZapStatusFailure, auth.Authenticate(req).StatusCode)
+		return time.Since(start)
+	}
+
+	slow := elapsed("slow")
+	unknown := elapsed("guest")
+	if unknown < slow/2 {
+		t.Errorf("unknown user refused in %s, a wrong password for the slow user in %s", unknown, slow)
+	}
+}
+
+func TestPlainAuthenticatorLoadHashes(t *testing.T) {
+	adminHash, err := hashPassword("Password", 1000)
+	require.NoError(t, err)
+	guestHash, err := hashPassword("guest", 1000)
+	require.NoError(t, err)
+
+	file := "./password_hashes_test.txt"
+	contents := fmt.Sprintf("# users\nadmin=%s\n\nguest=%s\n", adminHash, guestHash)
+	err = os.WriteFile(file, []byte(contents), 0600)
+	require.NoError(t, err)
+	defer os.Remove(file)
+
+	auth := NewPlainAuthenticator()
+	err = auth.LoadHashes(file)
+	require.NoError(t, err)
+
+	handler, err := NewZapHandler(auth)
+	require.NoError(t, err)
+	defer handler.Destroy()
+
+	server := NewSock(Pull, SockSetZapDomain("global"), SockSetPlainServer(1))
+	defer server.Destroy()
+
+	port, err := server.Bind("tcp://127.0.0.1:*")
+	require.NoError(t, err)
+
+	badClient := NewSock(Push, SockSetPlainUsername("guest"), SockSetPlainPassword("Password"))
+	defer badClient.Destroy()
+
+	goodClient := NewSock(Push, SockSetPlainUsername("admin"), SockSetPlainPassword("Password"))
+	defer goodClient.Destroy()
+
+	poller, err := NewPoller(server)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	err = badClient.Connect(fmt.Sprintf("tcp://127.0.0.1:%d", port))
+	require.NoError(t, err)
+
+	err = badClient.SendFrame([]byte("Bad"), FlagNone)
+	require.NoError(t, err)
+
+	s, err := poller.Wait(200)
+	require.NoError(t, err)
+	if s != nil {
+		t.Errorf("expected no message from unauthenticated client")
+	}
+
+	err = goodClient.Connect(fmt.Sprintf("tcp://127.0.0.1:%d", port))
+	require.NoError(t, err)
+
+	err = goodClient.SendFrame([]byte("Hello"), FlagNone)
+	require.NoError(t, err)
+
+	s, err = poller.Wait(2000)
+	require.NoError(t, err)
+	if want, have := server, s; want != have {
+		t.Fatalf("want %#v, have %#v", want, have)
+	}
+
+	msg, err := s.RecvMessage()
+	require.NoError(t, err)
+	assertEqual(t, "Hello", string(msg[0]))
+
+	err = os.WriteFile(file, []byte("admin\n"), 0600)
+	require.NoError(t, err)
+	err = auth.LoadHashes(file)
+	if err == nil {
+		t.Errorf("expected error for malformed hash file")
+	}
+}
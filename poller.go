@@ -11,43 +11,111 @@ zpoller_t *Poller_new(void *reader) {
 import "C"
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"syscall"
+	"time"
 	"unsafe"
 )
 
 // Poller provides a simple wrapper to ZeroMQ's zmq_poll API,
 // for the common case of reading from a number of sockets.
 // Sockets can be added and removed from the running poller.
+// A Poller is not safe for concurrent use, except for Interrupt,
+// which may be called from any goroutine to wake a waiting Poller.
 type Poller struct {
-	zpollerT *C.struct__zpoller_t
-	socks    []*Sock
+	zpollerT   *C.struct__zpoller_t
+	socks      []*Sock
+	wakeMu     sync.Mutex
+	wake       *wakeSignal
+	polled     *wakeSignal
+	destroyed  bool
+	expired    bool
+	terminated bool
+}
+
+// wakeSignal is an inproc PULL socket polled by one goroutine,
+// and a PUSH socket connected to it that any goroutine can use
+// to wake the polling one.
+type wakeSignal struct {
+	mu   sync.Mutex
+	pull *Sock
+	push *Sock
+}
+
+// newWakeSignal creates a wakeSignal on a unique endpoint
+func newWakeSignal() (*wakeSignal, error) {
+	uuid := C.zuuid_new()
+	endpoint := fmt.Sprintf("inproc://pollerwake_%s", C.GoString(C.zuuid_str(uuid)))
+	C.zuuid_destroy(&uuid)
+
+	pull, err := NewPull(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	push, err := NewPush(endpoint)
+	if err != nil {
+		pull.Destroy()
+		return nil, err
+	}
+
+	return &wakeSignal{pull: pull, push: push}, nil
+}
+
+// signal makes the PULL socket readable. It is safe to call
+// from any goroutine, and does nothing after destroy.
+func (w *wakeSignal) signal() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.push == nil {
+		return
+	}
+	// A full queue already holds a pending signal
+	w.push.SendFrame([]byte{}, FlagDontWait)
+}
+
+// drain discards pending signals. Only the polling
+// goroutine may call it.
+func (w *wakeSignal) drain() {
+	for w.pull.Pollin() {
+		_, _, err := w.pull.RecvFrame()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// destroy destroys both sockets
+func (w *wakeSignal) destroy() {
+	w.mu.Lock()
+	if w.push != nil {
+		w.push.Destroy()
+		w.push = nil
+	}
+	w.mu.Unlock()
+
+	if w.pull != nil {
+		w.pull.Destroy()
+		w.pull = nil
+	}
 }
 
 // NewPoller creates a new Poller instance.
 // It accepts one or more readers to poll.
 func NewPoller(readers ...*Sock) (*Poller, error) {
-	var p *Poller
-	if len(readers) == 0 || readers == nil {
-		p = &Poller{
-			zpollerT: C.Poller_new(nil),
-			socks:    make([]*Sock, 0),
-		}
-	} else {
-		p = &Poller{
-			zpollerT: C.Poller_new(unsafe.Pointer(readers[0].zsockT)),
-			socks:    make([]*Sock, 0),
-		}
-
-		p.socks = append(p.socks, readers[0])
-		if len(readers) == 1 {
-			return p, nil
-		}
+	p := &Poller{
+		zpollerT: C.Poller_new(nil),
+		socks:    make([]*Sock, 0),
+	}
 
-		for _, reader := range readers[1:] {
-			err := p.Add(reader)
-			if err != nil {
-				return nil, err
-			}
+	for _, reader := range readers {
+		err := p.Add(reader)
+		if err != nil {
+			p.Destroy()
+			return nil, err
 		}
 	}
 	return p, nil
@@ -63,42 +131,239 @@ func (p *Poller) Add(reader *Sock) error {
 	return nil
 }
 
-// Remove removes a Sock from the poller
-func (p *Poller) Remove(reader *Sock) {
-	numItems := len(p.socks)
-	for i := 0; i < numItems; i++ {
-		if p.socks[i] == reader {
-			if i == numItems-1 {
-				p.socks = p.socks[:i]
-			} else {
-				p.socks = append(p.socks[:i], p.socks[i+1:]...)
+// Remove removes a Sock from the poller. The socket is
+// unregistered from the underlying zpoller, so later calls
+// to Wait no longer return it.
+func (p *Poller) Remove(reader *Sock) error {
+	if p.zpollerT == nil {
+		return ErrWaitAfterDestroy
+	}
+	for i, sock := range p.socks {
+		if sock == reader {
+			rc := C.zpoller_remove(p.zpollerT, unsafe.Pointer(reader.zsockT))
+			if int(rc) == -1 {
+				return fmt.Errorf("error removing reader")
 			}
-			return
+			p.socks = append(p.socks[:i], p.socks[i+1:]...)
+			return nil
 		}
 	}
+	return fmt.Errorf("reader not in poller")
 }
 
 // Wait waits for the timeout period in milliseconds for a Pollin
-// event, and returns the first socket that returns one
+// event, and returns the first socket that returns one. On timeout
+// it returns a nil socket and a nil error. It returns ErrInterrupted
+// if the wait was woken by Interrupt, and ErrTerminated if the
+// ZeroMQ context was terminated. This package disables czmq's
+// signal handler, so signals such as SIGINT or SIGTERM do not
+// end the wait early.
 func (p *Poller) Wait(millis int) (*Sock, error) {
 	if p.zpollerT == nil {
 		// Null pointer. Something is wrong or we've already had `Destroy` invoked on us.
 		return nil, ErrWaitAfterDestroy
 	}
-	s := C.zpoller_wait(p.zpollerT, C.int(millis))
-	s = unsafe.Pointer(s)
-	if s == nil {
-		return nil, nil
+
+	var deadline time.Time
+	if millis > 0 {
+		deadline = time.Now().Add(time.Duration(millis) * time.Millisecond)
+	}
+
+	err := p.pollWake()
+	if err != nil {
+		return nil, err
+	}
+
+	p.expired = false
+	p.terminated = false
+	for {
+		s, err := C.zpoller_wait(p.zpollerT, C.int(millis))
+		if s != nil {
+			if p.polled != nil && unsafe.Pointer(p.polled.pull.zsockT) == s {
+				p.polled.drain()
+				return nil, ErrInterrupted
+			}
+			for _, sock := range p.socks {
+				if unsafe.Pointer(sock.zsockT) == s {
+					return sock, nil
+				}
+			}
+			return nil, fmt.Errorf("Could not match received pointer with %v with any socket (%v)", s, p.socks)
+		}
+
+		switch {
+		case bool(C.zpoller_expired(p.zpollerT)):
+			p.expired = true
+			return nil, nil
+
+		case err == syscall.EINTR:
+			// Interrupted by a signal, such as Go runtime
+			// preemption; wait out the remainder.
+			if millis > 0 {
+				millis = int(time.Until(deadline) / time.Millisecond)
+				if millis <= 0 {
+					p.expired = true
+					return nil, nil
+				}
+			}
+
+		case bool(C.zpoller_terminated(p.zpollerT)) || err == syscall.Errno(C.ETERM):
+			p.terminated = true
+			return nil, ErrTerminated
+
+		default:
+			return nil, err
+		}
+	}
+}
+
+// Expired returns true if the last call to Wait ended
+// because the timeout expired.
+func (p *Poller) Expired() bool {
+	return p.expired
+}
+
+// Terminated returns true if the last call to Wait ended
+// because the ZeroMQ context was terminated.
+func (p *Poller) Terminated() bool {
+	return p.terminated
+}
+
+// SetNonstop sets whether the Poller ignores czmq's interrupted
+// flag. czmq sets the flag from its SIGINT and SIGTERM handler,
+// which this package disables, so nonstop only matters if the
+// application sets the flag itself. Context termination ends a
+// Wait with ErrTerminated either way.
+func (p *Poller) SetNonstop(nonstop bool) {
+	C.zpoller_set_nonstop(p.zpollerT, C.bool(nonstop))
+}
+
+// WaitContext is like Wait, but also returns early with the
+// context's error if ctx is canceled while waiting.
+func (p *Poller) WaitContext(ctx context.Context, millis int) (*Sock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	_, err := p.wakeSignal()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			p.Interrupt()
+		case <-done:
+		}
+	}()
+
+	s, err := p.Wait(millis)
+	close(done)
+	<-stopped
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// The interrupt may have arrived after Wait returned
+		if p.polled != nil {
+			p.polled.drain()
+		}
+		return nil, ctxErr
+	}
+	return s, err
+}
+
+// Interrupt wakes a goroutine blocked in Wait, which then
+// returns ErrInterrupted. If no goroutine is waiting, the next
+// Wait returns immediately. It is safe to call Interrupt from
+// any goroutine, and it does nothing after Destroy.
+//
+// The signalling sockets Interrupt uses are only created by the
+// first call to Interrupt or WaitContext, so a Wait that was
+// already blocked before then is not woken. To interrupt a wait
+// that may start before the first Interrupt, use WaitContext.
+func (p *Poller) Interrupt() {
+	w, err := p.wakeSignal()
+	if err != nil {
+		return
+	}
+	w.signal()
+}
+
+// wakeSignal returns the signal Interrupt uses, creating it
+// on first use. It is safe to call from any goroutine.
+func (p *Poller) wakeSignal() (*wakeSignal, error) {
+	p.wakeMu.Lock()
+	defer p.wakeMu.Unlock()
+
+	if p.destroyed {
+		return nil, ErrWaitAfterDestroy
+	}
+	if p.wake == nil {
+		wake, err := newWakeSignal()
+		if err != nil {
+			return nil, err
+		}
+		p.wake = wake
+	}
+	return p.wake, nil
+}
+
+// pollWake adds the signal Interrupt uses to the zpoller,
+// once it has been created
+func (p *Poller) pollWake() error {
+	if p.polled != nil {
+		return nil
+	}
+
+	p.wakeMu.Lock()
+	wake := p.wake
+	p.wakeMu.Unlock()
+	if wake == nil {
+		return nil
+	}
+
+	rc := C.zpoller_add(p.zpollerT, unsafe.Pointer(wake.pull.zsockT))
+	if int(rc) == -1 {
+		return fmt.Errorf("error adding reader")
 	}
+	p.polled = wake
+	return nil
+}
+
+// WaitAll waits for the timeout period in milliseconds for a
+// Pollin event, and returns every socket that is readable
+// after the poll, starting with the one zpoller returned. This
+// keeps one busy socket from starving the others. On timeout
+// it returns an empty slice and a nil error.
+func (p *Poller) WaitAll(millis int) ([]*Sock, error) {
+	s, err := p.Wait(millis)
+	if err != nil || s == nil {
+		return nil, err
+	}
+
+	ready := []*Sock{s}
 	for _, sock := range p.socks {
-		if unsafe.Pointer(sock.zsockT) == s {
-			return sock, nil
+		if sock != s && sock.Pollin() {
+			ready = append(ready, sock)
 		}
 	}
-	return nil, fmt.Errorf("Could not match received pointer with %v with any socket (%v)", s, p.socks)
+	return ready, nil
 }
 
 // Destroy destroys the Poller
 func (p *Poller) Destroy() {
 	C.zpoller_destroy(&p.zpollerT)
+
+	p.wakeMu.Lock()
+	defer p.wakeMu.Unlock()
+
+	if p.wake != nil {
+		p.wake.destroy()
+		p.wake = nil
+	}
+	p.polled = nil
+	p.destroyed = true
 }
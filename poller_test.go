@@ -1,9 +1,11 @@
 package goczmq
 
 import (
+	"context"
 	"fmt"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestPollerNewNoSocks(t *testing.T) {
@@ -120,10 +122,175 @@ func TestPoller(t *testing.T) {
 		t.Errorf("want %#v, have %#v", want, have)
 	}
 
-	poller.Remove(pullSock2)
+	err = poller.Remove(pullSock2)
+	require.NoError(t, err)
 	if want, have := 1, len(poller.socks); want != have {
 		t.Errorf("want %#v, have %#v", want, have)
 	}
+
+	err = pushSock2.SendFrame([]byte("Removed"), FlagNone)
+	require.NoError(t, err)
+
+	s, err = poller.Wait(10)
+	require.NoError(t, err)
+	if s != nil {
+		t.Errorf("want nil, have %#v", s)
+	}
+
+	err = poller.Remove(pullSock2)
+	require.Error(t, err)
+}
+
+func TestPollerWaitAll(t *testing.T) {
+	pullSock1, err := NewPull("inproc://poller_waitall1")
+	require.NoError(t, err)
+	defer pullSock1.Destroy()
+
+	pullSock2, err := NewPull("inproc://poller_waitall2")
+	require.NoError(t, err)
+	defer pullSock2.Destroy()
+
+	poller, err := NewPoller(pullSock1, pullSock2)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	socks, err := poller.WaitAll(0)
+	require.NoError(t, err)
+	if want, have := 0, len(socks); want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	pushSock1, err := NewPush("inproc://poller_waitall1")
+	require.NoError(t, err)
+	defer pushSock1.Destroy()
+
+	pushSock2, err := NewPush("inproc://poller_waitall2")
+	require.NoError(t, err)
+	defer pushSock2.Destroy()
+
+	err = pushSock1.SendFrame([]byte("Hello"), FlagNone)
+	require.NoError(t, err)
+
+	err = pushSock2.SendFrame([]byte("World"), FlagNone)
+	require.NoError(t, err)
+
+	socks, err = poller.WaitAll(100)
+	require.NoError(t, err)
+	if want, have := 2, len(socks); want != have {
+		t.Fatalf("want %#v, have %#v", want, have)
+	}
+	if want, have := pullSock1, socks[0]; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := pullSock2, socks[1]; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+}
+
+func TestPollerInterrupt(t *testing.T) {
+	pullSock, err := NewPull("inproc://poller_interrupt")
+	require.NoError(t, err)
+	defer pullSock.Destroy()
+
+	poller, err := NewPoller(pullSock)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	// The signalling sockets are created by the first Interrupt
+	if poller.wake != nil {
+		t.Errorf("want no signalling sockets before Interrupt")
+	}
+
+	poller.Interrupt()
+	poller.Interrupt()
+
+	_, err = poller.Wait(-1)
+	if want, have := ErrInterrupted, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	s, err := poller.Wait(10)
+	require.NoError(t, err)
+	if s != nil {
+		t.Errorf("want nil, have %#v", s)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		poller.Interrupt()
+	}()
+
+	s, err = poller.Wait(-1)
+	if want, have := ErrInterrupted, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if s != nil {
+		t.Errorf("want nil, have %#v", s)
+	}
+}
+
+func TestPollerExpired(t *testing.T) {
+	pullSock, err := NewPull("inproc://poller_expired")
+	require.NoError(t, err)
+	defer pullSock.Destroy()
+
+	poller, err := NewPoller(pullSock)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	poller.SetNonstop(true)
+
+	s, err := poller.Wait(10)
+	require.NoError(t, err)
+	if s != nil {
+		t.Errorf("want nil, have %#v", s)
+	}
+	if want, have := true, poller.Expired(); want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := false, poller.Terminated(); want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	poller.Interrupt()
+	_, err = poller.Wait(10)
+	if want, have := ErrInterrupted, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := false, poller.Expired(); want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+}
+
+func TestPollerWaitContext(t *testing.T) {
+	pullSock, err := NewPull("inproc://poller_waitcontext")
+	require.NoError(t, err)
+	defer pullSock.Destroy()
+
+	poller, err := NewPoller(pullSock)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	_, err = poller.WaitContext(ctx, -1)
+	if want, have := context.DeadlineExceeded, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	pushSock, err := NewPush("inproc://poller_waitcontext")
+	require.NoError(t, err)
+	defer pushSock.Destroy()
+
+	err = pushSock.SendFrame([]byte("Hello"), FlagNone)
+	require.NoError(t, err)
+
+	s, err := poller.WaitContext(context.Background(), -1)
+	require.NoError(t, err)
+	if want, have := pullSock, s; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
 }
 
 func TestPollerAfterDestroy(t *testing.T) {
@@ -0,0 +1,276 @@
+package goczmq
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults replacing a window or ban duration passed to
+// NewRateLimiter that is not positive
+const (
+	defaultRateLimitWindow = time.Minute
+	defaultBanDuration     = time.Minute
+)
+
+// Ban kinds, see BanEvent
+const (
+	BanAddress  = "address"
+	BanIdentity = "identity"
+)
+
+// BanEvent reports that a peer address or client identity was
+// banned or unbanned by a RateLimiter. Identity bans apply to the
+// PLAIN username or CURVE public key text of the client. Until
+// is when a ban ends, and nil for an unban.
+type BanEvent struct {
+	Time   time.Time  `json:"time"`
+	Kind   string     `json:"kind"`
+	Value  string     `json:"value"`
+	Banned bool       `json:"banned"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// banKey identifies a banned address or identity
+type banKey struct {
+	kind  string
+	value string
+}
+
+// ban is an active ban and the timer that lifts it
+type ban struct {
+	until time.Time
+	timer *time.Timer
+}
+
+// RateLimiter is an Authenticator that protects another one,
+// such as an AuthPolicy or PlainAuthenticator, from brute force
+// attempts. It counts the requests the wrapped authenticator
+// refuses, per peer address and per client identity, and when
+// either reaches the failure limit within the window, bans it for
+// the ban duration. Requests from a banned address or identity are
+// refused without calling the wrapped authenticator, acting as a
+// temporary deny list entry. A successful request clears the
+// failures counted against its address and identity. Refusing a
+// banned peer is cheap, which bounds the CPU an attacker can make
+// a PlainAuthenticator spend on password hashing.
+type RateLimiter struct {
+	auth        Authenticator
+	maxFailures int
+	window      time.Duration
+	banDuration time.Duration
+	mu          sync.Mutex
+	failures    map[banKey][]time.Time
+	bans        map[banKey]*ban
+	lastSweep   time.Time
+	onBan       func(BanEvent)
+}
+
+// NewRateLimiter creates a RateLimiter that bans an address or
+// identity for banDuration after maxFailures failed requests
+// within window. A maxFailures below 1 is treated as 1, banning
+// on the first failure. A window that is not positive is treated
+// as one minute, as such a window would never count enough
+// failures, and so is a ban duration that is not positive, as such
+// a ban would be lifted as soon as it was made.
+func NewRateLimiter(auth Authenticator, maxFailures int, window, banDuration time.Duration) *RateLimiter {
+	if maxFailures < 1 {
+		maxFailures = 1
+	}
+	if window <= 0 {
+		window = defaultRateLimitWindow
+	}
+	if banDuration <= 0 {
+		banDuration = defaultBanDuration
+	}
+
+	return &RateLimiter{
+		auth:        auth,
+		maxFailures: maxFailures,
+		window:      window,
+		banDuration: banDuration,
+		failures:    make(map[banKey][]time.Time),
+		bans:        make(map[banKey]*ban),
+	}
+}
+
+// SetBanFunc sets a function that is called with a BanEvent
+// whenever an address or identity is banned or unbanned. It is
+// called without the RateLimiter's lock held, from the goroutine
+// that authenticated the request, called Ban or Unban, or from a
+// timer goroutine when a ban expires.
+func (r *RateLimiter) SetBanFunc(onBan func(BanEvent)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onBan = onBan
+}
+
+// Ban bans an address or identity for d, replacing any
+// existing ban on it. Kind is BanAddress or BanIdentity.
+func (r *RateLimiter) Ban(kind, value string, d time.Duration) {
+	r.mu.Lock()
+	event := r.ban(banKey{kind, value}, time.Now(), d)
+	onBan := r.onBan
+	r.mu.Unlock()
+
+	if onBan != nil {
+		onBan(event)
+	}
+}
+
+// Unban lifts a ban and clears the failures counted against
+// an address or identity.
+func (r *RateLimiter) Unban(kind, value string) {
+	key := banKey{kind, value}
+
+	r.mu.Lock()
+	delete(r.failures, key)
+	b, ok := r.bans[key]
+	if ok {
+		b.timer.Stop()
+		delete(r.bans, key)
+	}
+	onBan := r.onBan
+	r.mu.Unlock()
+
+	if ok && onBan != nil {
+		onBan(BanEvent{Time: time.Now(), Kind: kind, Value: value})
+	}
+}
+
+// Bans returns the active bans, ordered by kind and value
+func (r *RateLimiter) Bans() []BanEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bans := make([]BanEvent, 0, len(r.bans))
+	for key, b := range r.bans {
+		until := b.until
+		bans = append(bans, BanEvent{Kind: key.kind, Value: key.value, Banned: true, Until: &until})
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if bans[i].Kind != bans[j].Kind {
+			return bans[i].Kind < bans[j].Kind
+		}
+		return bans[i].Value < bans[j].Value
+	})
+	return bans
+}
+
+// Authenticate satisfies Authenticator
+func (r *RateLimiter) Authenticate(req *ZapRequest) ZapResponse {
+	keys := []banKey{{BanAddress, req.Address}}
+	if identity := zapIdentity(req); identity != "" {
+		keys = append(keys, banKey{BanIdentity, identity})
+	}
+
+	r.mu.Lock()
+	for _, key := range keys {
+		if _, ok := r.bans[key]; ok {
+			r.mu.Unlock()
+			return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Too many failed attempts"}
+		}
+	}
+	r.mu.Unlock()
+
+	resp := r.auth.Authenticate(req)
+
+	r.mu.Lock()
+	now := time.Now()
+	var events []BanEvent
+	switch resp.StatusCode {
+	case ZapStatusSuccess:
+		for _, key := range keys {
+			delete(r.failures, key)
+		}
+
+	case ZapStatusFailure:
+		for _, key := range keys {
+			if r.fail(key, now) {
+				events = append(events, r.ban(key, now, r.banDuration))
+			}
+		}
+	}
+	r.sweep(now)
+	onBan := r.onBan
+	r.mu.Unlock()
+
+	if onBan != nil {
+		for _, event := range events {
+			onBan(event)
+		}
+	}
+	return resp
+}
+
+// fail counts a failure and reports whether it reaches the limit
+func (r *RateLimiter) fail(key banKey, now time.Time) bool {
+	failures := append(r.recent(r.failures[key], now), now)
+	if len(failures) >= r.maxFailures {
+		delete(r.failures, key)
+		return true
+	}
+	r.failures[key] = failures
+	return false
+}
+
+// recent returns the failures still within the window
+func (r *RateLimiter) recent(failures []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(failures) && now.Sub(failures[i]) >= r.window {
+		i++
+	}
+	return failures[i:]
+}
+
+// sweep forgets failures older than the window, at most once
+// per window, so peers that stop trying do not use memory
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < r.window {
+		return
+	}
+	r.lastSweep = now
+
+	for key, failures := range r.failures {
+		failures = r.recent(failures, now)
+		if len(failures) == 0 {
+			delete(r.failures, key)
+		} else {
+			r.failures[key] = failures
+		}
+	}
+}
+
+// ban records a ban, with a timer to lift it, and returns its
+// event. The lock must be held.
+func (r *RateLimiter) ban(key banKey, now time.Time, d time.Duration) BanEvent {
+	if b, ok := r.bans[key]; ok {
+		b.timer.Stop()
+	}
+
+	b := &ban{until: now.Add(d)}
+	b.timer = time.AfterFunc(d, func() {
+		r.expire(key, b)
+	})
+	r.bans[key] = b
+
+	until := b.until
+	return BanEvent{Time: now, Kind: key.kind, Value: key.value, Banned: true, Until: &until}
+}
+
+// expire lifts a ban when its time is up, unless it was replaced
+func (r *RateLimiter) expire(key banKey, b *ban) {
+	r.mu.Lock()
+	if r.bans[key] != b {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.bans, key)
+	onBan := r.onBan
+	r.mu.Unlock()
+
+	if onBan != nil {
+		onBan(BanEvent{Time: time.Now(), Kind: key.kind, Value: key.value})
+	}
+}
@@ -0,0 +1,127 @@
+package goczmq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	calls := 0
+	limiter := NewRateLimiter(AuthenticatorFunc(func(req *ZapRequest) ZapResponse {
+		calls++
+		if string(req.Credentials[1]) == "Password" {
+			return ZapResponse{StatusCode: ZapStatusSuccess, UserID: string(req.Credentials[0])}
+		}
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Invalid username or password"}
+	}), 3, time.Minute, 100*time.Millisecond)
+
+	events := make(chan BanEvent, 10)
+	limiter.SetBanFunc(func(e BanEvent) {
+		events <- e
+	})
+
+	request := func(address, username, password string) string {
+		return limiter.Authenticate(&ZapRequest{
+			Address:     address,
+			Mechanism:   "PLAIN",
+			Credentials: [][]byte{[]byte(username), []byte(password)},
+		}).StatusCode
+	}
+
+	// A success clears earlier failures
+	assertEqual(t, ZapStatusFailure, request("10.0.0.1", "admin", "bad"))
+	assertEqual(t, ZapStatusFailure, request("10.0.0.1", "admin", "bad"))
+	assertEqual(t, ZapStatusSuccess, request("10.0.0.1", "admin", "Password"))
+	assertEqual(t, ZapStatusFailure, request("10.0.0.1", "admin", "bad"))
+	assertEqual(t, 0, len(limiter.Bans()))
+
+	// Failures from several addresses add up against the username
+	assertEqual(t, ZapStatusFailure, request("10.0.0.2", "admin", "bad"))
+	assertEqual(t, ZapStatusFailure, request("10.0.0.3", "admin", "bad"))
+
+	e := <-events
+	assertEqual(t, true, e.Banned)
+	assertEqual(t, BanIdentity, e.Kind)
+	assertEqual(t, "admin", e.Value)
+	require.NotNil(t, e.Until)
+
+	calls = 0
+	assertEqual(t, ZapStatusFailure, request("10.0.0.4", "admin", "Password"))
+	assertEqual(t, 0, calls)
+
+	bans := limiter.Bans()
+	require.Len(t, bans, 1)
+	assertEqual(t, "admin", bans[0].Value)
+
+	e = <-events
+	assertEqual(t, false, e.Banned)
+	assertEqual(t, "admin", e.Value)
+	if e.Until != nil {
+		t.Errorf("want no end time for an unban, got %v", e.Until)
+	}
+	assertEqual(t, ZapStatusSuccess, request("10.0.0.4", "admin", "Password"))
+
+	// Manual bans and unbans
+	limiter.Ban(BanAddress, "10.0.0.5", time.Minute)
+	e = <-events
+	assertEqual(t, true, e.Banned)
+	assertEqual(t, BanAddress, e.Kind)
+	assertEqual(t, ZapStatusFailure, request("10.0.0.5", "guest", "Password"))
+
+	limiter.Unban(BanAddress, "10.0.0.5")
+	e = <-events
+	assertEqual(t, false, e.Banned)
+	assertEqual(t, ZapStatusSuccess, request("10.0.0.5", "guest", "Password"))
+	assertEqual(t, 0, len(limiter.Bans()))
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+	limiter := NewRateLimiter(AuthenticatorFunc(func(req *ZapRequest) ZapResponse {
+		return ZapResponse{StatusCode: ZapStatusFailure}
+	}), 2, 50*time.Millisecond, time.Minute)
+
+	req := &ZapRequest{Address: "10.0.0.1", Mechanism: "NULL"}
+	limiter.Authenticate(req)
+	time.Sleep(60 * time.Millisecond)
+	limiter.Authenticate(req)
+	assertEqual(t, 0, len(limiter.Bans()))
+
+	limiter.Authenticate(req)
+	assertEqual(t, 1, len(limiter.Bans()))
+	limiter.Unban(BanAddress, "10.0.0.1")
+}
+
+func TestRateLimiterInvalidLimits(t *testing.T) {
+	refuse := AuthenticatorFunc(func(req *ZapRequest) ZapResponse {
+		return ZapResponse{StatusCode: ZapStatusFailure}
+	})
+	req := &ZapRequest{Address: "10.0.0.1", Mechanism: "NULL"}
+
+	// No failures allowed bans on the first one
+	limiter := NewRateLimiter(refuse, 0, time.Minute, time.Minute)
+	limiter.Authenticate(req)
+	assertEqual(t, 1, len(limiter.Bans()))
+	limiter.Unban(BanAddress, "10.0.0.1")
+
+	limiter = NewRateLimiter(refuse, -1, time.Minute, time.Minute)
+	limiter.Authenticate(req)
+	assertEqual(t, 1, len(limiter.Bans()))
+	limiter.Unban(BanAddress, "10.0.0.1")
+
+	// A zero window still counts failures towards a ban
+	limiter = NewRateLimiter(refuse, 2, 0, time.Minute)
+	limiter.Authenticate(req)
+	assertEqual(t, 0, len(limiter.Bans()))
+	limiter.Authenticate(req)
+	assertEqual(t, 1, len(limiter.Bans()))
+	limiter.Unban(BanAddress, "10.0.0.1")
+
+	// A zero ban duration still bans for a while
+	limiter = NewRateLimiter(refuse, 1, time.Minute, 0)
+	limiter.Authenticate(req)
+	time.Sleep(10 * time.Millisecond)
+	assertEqual(t, 1, len(limiter.Bans()))
+	limiter.Unban(BanAddress, "10.0.0.1")
+}
@@ -0,0 +1,205 @@
+package goczmq
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReaderFunc handles a readable socket registered with a Reactor.
+// Returning an error stops the Reactor, and Run returns the error.
+type ReaderFunc func(sock *Sock) error
+
+// TimerFunc handles an expired Reactor timer. Returning an error
+// stops the Reactor, and Run returns the error.
+type TimerFunc func() error
+
+// reactorTimer is a timer registered with a Reactor
+type reactorTimer struct {
+	delay   time.Duration
+	times   int
+	when    time.Time
+	handler TimerFunc
+}
+
+// Reactor is an event loop in the style of CZMQ's zloop. It
+// calls handlers for readable sockets and for expired timers
+// until a handler returns an error or its context is canceled.
+// Actors such as Monitor or Beacon can be driven by a Reactor
+// by registering their Socket. Readers and timers may be added
+// and removed before Run or from within handlers; a Reactor is
+// not otherwise safe for concurrent use.
+type Reactor struct {
+	poller    *Poller
+	readers   map[*Sock]ReaderFunc
+	timers    map[int]*reactorTimer
+	nextTimer int
+}
+
+// NewReactor creates a new Reactor with no readers or timers.
+func NewReactor() (*Reactor, error) {
+	poller, err := NewPoller()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reactor{
+		poller:  poller,
+		readers: make(map[*Sock]ReaderFunc),
+		timers:  make(map[int]*reactorTimer),
+	}
+	return r, nil
+}
+
+// AddReader registers a handler to be called each time
+// sock is readable.
+func (r *Reactor) AddReader(sock *Sock, handler ReaderFunc) error {
+	if _, ok := r.readers[sock]; ok {
+		return fmt.Errorf("reader already added to reactor")
+	}
+
+	err := r.poller.Add(sock)
+	if err != nil {
+		return err
+	}
+	r.readers[sock] = handler
+	return nil
+}
+
+// RemoveReader unregisters a socket from the Reactor.
+func (r *Reactor) RemoveReader(sock *Sock) error {
+	if _, ok := r.readers[sock]; !ok {
+		return fmt.Errorf("reader not in reactor")
+	}
+
+	delete(r.readers, sock)
+	return r.poller.Remove(sock)
+}
+
+// reactorMinRepeat is the shortest delay of a timer that
+// repeats indefinitely, so it cannot spin the Reactor
+const reactorMinRepeat = time.Millisecond
+
+// AddTimer registers a handler to be called after delay, times
+// times, or indefinitely if times is 0. Timers that repeat
+// indefinitely fire at most once per millisecond. It returns
+// a timer id that can be passed to CancelTimer.
+func (r *Reactor) AddTimer(delay time.Duration, times int, handler TimerFunc) int {
+	if times == 0 && delay < reactorMinRepeat {
+		delay = reactorMinRepeat
+	}
+
+	r.nextTimer++
+	r.timers[r.nextTimer] = &reactorTimer{
+		delay:   delay,
+		times:   times,
+		when:    time.Now().Add(delay),
+		handler: handler,
+	}
+	return r.nextTimer
+}
+
+// CancelTimer cancels a timer registered with AddTimer.
+func (r *Reactor) CancelTimer(id int) error {
+	if _, ok := r.timers[id]; !ok {
+		return fmt.Errorf("timer not in reactor")
+	}
+	delete(r.timers, id)
+	return nil
+}
+
+// Run runs the Reactor until a handler returns an error, which
+// Run returns, or ctx is canceled, in which case it returns the
+// context's error.
+func (r *Reactor) Run(ctx context.Context) error {
+	// Create the signal before waiting, so that canceling
+	// ctx can interrupt the first wait
+	_, err := r.poller.wakeSignal()
+	if err != nil {
+		return err
+	}
+
+	stop := context.AfterFunc(ctx, r.poller.Interrupt)
+	defer stop()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		socks, err := r.poller.WaitAll(r.timeout())
+		if err == ErrInterrupted {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, sock := range socks {
+			handler, ok := r.readers[sock]
+			if !ok {
+				continue
+			}
+			err = handler(sock)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = r.runTimers()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Destroy destroys the Reactor. Registered sockets are
+// not destroyed.
+func (r *Reactor) Destroy() {
+	r.poller.Destroy()
+}
+
+// timeout returns the milliseconds until the next timer
+// expires, or -1 if there are no timers.
+func (r *Reactor) timeout() int {
+	if len(r.timers) == 0 {
+		return -1
+	}
+
+	var next time.Time
+	for _, timer := range r.timers {
+		if next.IsZero() || timer.when.Before(next) {
+			next = timer.when
+		}
+	}
+
+	wait := time.Until(next)
+	if wait <= 0 {
+		return 0
+	}
+	return int((wait + time.Millisecond - 1) / time.Millisecond)
+}
+
+// runTimers calls the handlers of all expired timers.
+func (r *Reactor) runTimers() error {
+	now := time.Now()
+	for id, timer := range r.timers {
+		if timer.when.After(now) {
+			continue
+		}
+
+		if timer.times > 0 {
+			timer.times--
+			if timer.times == 0 {
+				delete(r.timers, id)
+			}
+		}
+		timer.when = now.Add(timer.delay)
+
+		err := timer.handler()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package goczmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactor(t *testing.T) {
+	reactor, err := NewReactor()
+	require.NoError(t, err)
+	defer reactor.Destroy()
+
+	pull, err := NewPull("inproc://reactor")
+	require.NoError(t, err)
+	defer pull.Destroy()
+
+	push, err := NewPush("inproc://reactor")
+	require.NoError(t, err)
+	defer push.Destroy()
+
+	errDone := errors.New("done")
+	var received []string
+
+	err = reactor.AddReader(pull, func(sock *Sock) error {
+		frame, _, err := sock.RecvFrame()
+		if err != nil {
+			return err
+		}
+		received = append(received, string(frame))
+		if len(received) == 3 {
+			return errDone
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	ticks := 0
+	reactor.AddTimer(time.Millisecond, 3, func() error {
+		ticks++
+		return push.SendFrame([]byte("tick"), FlagNone)
+	})
+
+	cancelled := reactor.AddTimer(time.Millisecond, 0, func() error {
+		return errors.New("cancelled timer fired")
+	})
+	err = reactor.CancelTimer(cancelled)
+	require.NoError(t, err)
+
+	err = reactor.Run(context.Background())
+	if want, have := errDone, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := 3, ticks; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	err = reactor.RemoveReader(pull)
+	require.NoError(t, err)
+
+	err = reactor.RemoveReader(pull)
+	require.Error(t, err)
+}
+
+func TestReactorContext(t *testing.T) {
+	reactor, err := NewReactor()
+	require.NoError(t, err)
+	defer reactor.Destroy()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	err = reactor.Run(ctx)
+	if want, have := context.DeadlineExceeded, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+}
+
+func TestReactorZeroDelayTimer(t *testing.T) {
+	reactor, err := NewReactor()
+	require.NoError(t, err)
+	defer reactor.Destroy()
+
+	ticks := 0
+	reactor.AddTimer(0, 0, func() error {
+		ticks++
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	err = reactor.Run(ctx)
+	if want, have := context.DeadlineExceeded, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if ticks > 60 {
+		t.Errorf("zero delay timer fired %d times in 50ms", ticks)
+	}
+}
+
+func TestReactorMonitor(t *testing.T) {
+	reactor, err := NewReactor()
+	require.NoError(t, err)
+	defer reactor.Destroy()
+
+	sock := NewSock(Dealer)
+	defer sock.Destroy()
+
+	monitor := NewMonitor(sock)
+	defer monitor.Destroy()
+
+	err = monitor.Listen("LISTENING")
+	require.NoError(t, err)
+
+	err = monitor.Start()
+	require.NoError(t, err)
+
+	errDone := errors.New("done")
+	err = reactor.AddReader(monitor.Socket(), func(s *Sock) error {
+		msg, err := s.RecvMessage()
+		if err != nil {
+			return err
+		}
+		if want, have := "LISTENING", string(msg[0]); want != have {
+			t.Errorf("want %#v, have %#v", want, have)
+		}
+		return errDone
+	})
+	require.NoError(t, err)
+
+	reactor.AddTimer(time.Millisecond, 1, func() error {
+		_, err := sock.Bind("tcp://127.0.0.1:*")
+		return err
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	err = reactor.Run(ctx)
+	if want, have := errDone, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+}
+
+func TestReactorBeacon(t *testing.T) {
+	reactor, err := NewReactor()
+	require.NoError(t, err)
+	defer reactor.Destroy()
+
+	speaker := NewBeacon()
+	defer speaker.Destroy()
+
+	address, err := speaker.Configure(9997)
+	require.NoError(t, err)
+	if address == "" {
+		t.Skip("no broadcast interface available")
+	}
+
+	listener := NewBeacon()
+	defer listener.Destroy()
+
+	_, err = listener.Configure(9997)
+	require.NoError(t, err)
+
+	err = listener.Subscribe("HI")
+	require.NoError(t, err)
+
+	errDone := errors.New("done")
+	err = reactor.AddReader(listener.Socket(), func(s *Sock) error {
+		msg, err := s.RecvMessage()
+		if err != nil {
+			return err
+		}
+		if want, have := 2, len(msg); want != have {
+			t.Fatalf("want %#v, have %#v", want, have)
+		}
+		if want, have := "HI", string(msg[1]); want != have {
+			t.Errorf("want %#v, have %#v", want, have)
+		}
+		return errDone
+	})
+	require.NoError(t, err)
+
+	reactor.AddTimer(time.Millisecond, 1, func() error {
+		return speaker.Publish("HI", 100)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	err = reactor.Run(ctx)
+	if want, have := errDone, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+}
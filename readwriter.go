@@ -14,6 +14,10 @@ type ReadWriter struct {
 	frame         []byte
 	currentIndex  int
 	timeoutMillis int
+	framing       Framing
+	readBuf       []byte
+	writeBuf      []byte
+	peers         map[string]bool
 }
 
 // NewReadWriter accepts a sock and returns a goczmq.ReadWriter. The
@@ -37,8 +41,29 @@ func (r *ReadWriter) SetTimeout(ms int) {
 	r.timeoutMillis = ms
 }
 
+// SetFraming switches the ReadWriter to stream mode. Each
+// multipart message received is encoded with f and can be read
+// across any number of Read calls, and bytes passed to Write are
+// buffered until f decodes a complete message, which is then sent.
+// Read does not return io.EOF at message boundaries in stream
+// mode, only when a peer of a Stream socket disconnects or the
+// ZeroMQ context is terminated, and Router and Stream identity
+// frames are passed through as the first frame of each message
+// rather than tracked as client ids. Passing nil restores the
+// default single frame mode and discards any buffered data.
+func (r *ReadWriter) SetFraming(f Framing) {
+	r.framing = f
+	r.readBuf = nil
+	r.writeBuf = nil
+	r.peers = nil
+}
+
 // Read satisifies io.Read
 func (r *ReadWriter) Read(p []byte) (int, error) {
+	if r.framing != nil {
+		return r.readStream(p)
+	}
+
 	var totalRead int
 	var totalFrame int
 
@@ -83,8 +108,90 @@ func (r *ReadWriter) Read(p []byte) (int, error) {
 	return totalRead, err
 }
 
+// readStream reads the stream encoding of received messages
+func (r *ReadWriter) readStream(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for len(r.readBuf) == 0 {
+		s, err := r.poller.Wait(r.timeoutMillis)
+		if err == ErrTerminated {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if s == nil {
+			return 0, ErrTimeout
+		}
+
+		msg, err := s.RecvMessage()
+		if err != nil {
+			return 0, err
+		}
+
+		if s.GetType() == Stream && len(msg) == 2 && len(msg[1]) == 0 {
+			// A Stream socket reports both a peer connecting
+			// and disconnecting with an empty message
+			id := string(msg[0])
+			if r.peers[id] {
+				delete(r.peers, id)
+				return 0, io.EOF
+			}
+			if r.peers == nil {
+				r.peers = make(map[string]bool)
+			}
+			r.peers[id] = true
+			continue
+		}
+
+		r.readBuf, err = r.framing.Encode(r.readBuf[:0], msg)
+		if err != nil {
+			r.readBuf = r.readBuf[:0]
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.readBuf)
+	r.readBuf = r.readBuf[n:]
+	return n, nil
+}
+
+// writeStream buffers p and sends every complete message
+// the framing decodes from the buffer. If a message cannot be
+// decoded or sent, the buffer is discarded and the count of bytes
+// from p that were sent before it is returned with the error.
+func (r *ReadWriter) writeStream(p []byte) (int, error) {
+	buffered := len(r.writeBuf)
+	r.writeBuf = append(r.writeBuf, p...)
+
+	var sent int
+	for {
+		msg, n, err := r.framing.Decode(r.writeBuf[sent:])
+		if err == nil && n > 0 {
+			err = r.sock.SendMessage(msg)
+		}
+		if err != nil {
+			r.writeBuf = nil
+			return max(sent-buffered, 0), err
+		}
+		if n == 0 {
+			break
+		}
+		sent += n
+	}
+
+	r.writeBuf = append(r.writeBuf[:0], r.writeBuf[sent:]...)
+	return len(p), nil
+}
+
 // Write satisfies io.Write
 func (r *ReadWriter) Write(p []byte) (int, error) {
+	if r.framing != nil {
+		return r.writeStream(p)
+	}
+
 	var total int
 	if r.sock.GetType() == Router {
 		err := r.sock.SendFrame(r.GetLastClientID(), FlagMore)
@@ -1,8 +1,10 @@
 package goczmq
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"net"
 	"testing"
 )
 
@@ -185,6 +187,211 @@ func TestReadWriterDoesNotSupportMultiPart(t *testing.T) {
 
 }
 
+func TestReadWriterFraming(t *testing.T) {
+	endpoint := "inproc://testReadWriterFraming"
+
+	pushSock, err := NewPush(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushReadWriter, err := NewReadWriter(pushSock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pushReadWriter.Destroy()
+
+	pullSock, err := NewPull(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pullReadWriter, err := NewReadWriter(pullSock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pullReadWriter.Destroy()
+
+	framing := DelimiterFraming{FrameDelimiter: []byte(" "), MessageDelimiter: []byte("\n")}
+	pushReadWriter.SetFraming(framing)
+	pullReadWriter.SetFraming(framing)
+
+	for _, chunk := range []string{"Hel", "lo Wor", "ld\nBye\n"} {
+		n, err := pushReadWriter.Write([]byte(chunk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := len(chunk), n; want != got {
+			t.Errorf("want %d, got %d", want, got)
+		}
+	}
+
+	b := make([]byte, 4)
+	var received []byte
+	for len(received) < len("Hello World\nBye\n") {
+		n, err := pullReadWriter.Read(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, b[:n]...)
+	}
+
+	if want, got := "Hello World\nBye\n", string(received); want != got {
+		t.Errorf("want '%q', got '%q'", want, got)
+	}
+
+	pullReadWriter.SetTimeout(1)
+	_, err = pullReadWriter.Read(b)
+	if want, got := ErrTimeout, err; want != got {
+		t.Errorf("want '%v', got '%v'", want, got)
+	}
+}
+
+func TestReadWriterFramingCopy(t *testing.T) {
+	endpoint := "inproc://testReadWriterFramingCopy"
+
+	pushSock, err := NewPush(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushReadWriter, err := NewReadWriter(pushSock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pushReadWriter.Destroy()
+
+	pullSock, err := NewPull(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pullReadWriter, err := NewReadWriter(pullSock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pullReadWriter.Destroy()
+
+	framing := LengthPrefixFraming{}
+	pushReadWriter.SetFraming(framing)
+	pullReadWriter.SetFraming(framing)
+
+	var stream []byte
+	for i := 0; i < 64; i++ {
+		frame := bytes.Repeat([]byte{byte(i)}, 16384)
+		stream, err = framing.Encode(stream, [][]byte{[]byte("part"), frame})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := io.Copy(pushReadWriter, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := int64(len(stream)), n; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+
+	received := make([]byte, len(stream))
+	_, err = io.ReadFull(pullReadWriter, received)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(stream, received) {
+		t.Errorf("received stream does not match sent stream")
+	}
+}
+
+func TestReadWriterFramingWriteError(t *testing.T) {
+	endpoint := "inproc://testReadWriterFramingWriteError"
+
+	pushSock, err := NewPush(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushReadWriter, err := NewReadWriter(pushSock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pushReadWriter.Destroy()
+
+	pullSock, err := NewPull(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pullSock.Destroy()
+
+	framing := LengthPrefixFraming{MaxFrameSize: 4}
+	pushReadWriter.SetFraming(framing)
+
+	valid, err := framing.Encode(nil, [][]byte{[]byte("ok")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := framing.Encode(append([]byte{}, valid...), [][]byte{[]byte("too long")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := pushReadWriter.Write(stream)
+	if err == nil {
+		t.Fatal("expected an error for an oversized frame")
+	}
+	if want, got := len(valid), n; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestReadWriterFramingStreamEOF(t *testing.T) {
+	sock := NewSock(Stream)
+	port, err := sock.Bind("tcp://127.0.0.1:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rw, err := NewReadWriter(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Destroy()
+
+	framing := LengthPrefixFraming{}
+	rw.SetFraming(framing)
+	rw.SetTimeout(2000)
+
+	client, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Write([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	// io.Copy ends at the io.EOF reported for the disconnect
+	var received bytes.Buffer
+	_, err = io.Copy(&received, rw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, _, err := framing.Decode(received.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(msg); want != got {
+		t.Fatalf("want %d, got %d", want, got)
+	}
+	if want, got := "hello", string(msg[1]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+}
+
 func benchmarkReadWriter(size int, b *testing.B) {
 	endpoint := fmt.Sprintf("inproc://benchReadWriter%d", size)
 
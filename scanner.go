@@ -0,0 +1,233 @@
+package goczmq
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// MessageScanner reads messages from a Sock in a loop, in the
+// style of bufio.Scanner. Successive calls to Scan step through
+// the messages received on the socket, and Message returns the
+// current one. Scanning stops when the context is done, a wait
+// times out, the socket or its poller is destroyed or the context
+// is terminated, or receiving fails. Err then reports why.
+//
+//	scanner, err := goczmq.NewMessageScanner(ctx, sock)
+//	for scanner.Scan() {
+//		handle(scanner.Message())
+//	}
+//	if err := scanner.Err(); err != nil {
+//		...
+//	}
+type MessageScanner struct {
+	ctx           context.Context
+	sock          *Sock
+	poller        *Poller
+	ownPoller     bool
+	timeoutMillis int
+	msg           [][]byte
+	err           error
+	done          bool
+	unwatch       func()
+}
+
+// NewMessageScanner creates a MessageScanner for sock that
+// stops when ctx is done. The scanner polls the socket with its
+// own Poller, which is released when Scan returns false; if
+// scanning is abandoned earlier, call Destroy.
+func NewMessageScanner(ctx context.Context, sock *Sock) (*MessageScanner, error) {
+	if sock.zsockT == nil {
+		// A destroyed socket has nothing left to scan
+		return &MessageScanner{ctx: ctx, sock: sock, done: true}, nil
+	}
+
+	poller, err := NewPoller(sock)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageScanner{
+		ctx:           ctx,
+		sock:          sock,
+		poller:        poller,
+		ownPoller:     true,
+		timeoutMillis: -1,
+	}, nil
+}
+
+// SetTimeout sets how long in milliseconds Scan waits for the
+// next message. If none arrives in time, scanning stops and Err
+// returns ErrTimeout. The default is to wait indefinitely.
+func (m *MessageScanner) SetTimeout(ms int) {
+	m.timeoutMillis = ms
+}
+
+// Scan waits for and receives the next message, which is then
+// available through Message. It returns false when scanning stops.
+func (m *MessageScanner) Scan() bool {
+	if m.done {
+		return false
+	}
+
+	m.msg = nil
+	if m.sock.zsockT == nil {
+		return m.stop(nil)
+	}
+
+	if m.unwatch == nil {
+		err := m.watch()
+		if err != nil {
+			return m.stop(err)
+		}
+	}
+
+	var s *Sock
+	var err error
+	for {
+		if ctxErr := m.ctx.Err(); ctxErr != nil {
+			return m.stop(ctxErr)
+		}
+		s, err = m.poller.Wait(m.timeoutMillis)
+		if err != ErrInterrupted {
+			break
+		}
+	}
+	if err != nil {
+		return m.stop(err)
+	}
+	if s == nil {
+		return m.stop(ErrTimeout)
+	}
+
+	msg, err := s.RecvMessage()
+	if err != nil {
+		return m.stop(err)
+	}
+	m.msg = msg
+	return true
+}
+
+// Message returns the message received by the last call to Scan
+func (m *MessageScanner) Message() [][]byte {
+	return m.msg
+}
+
+// Err returns the error that stopped scanning. It returns nil
+// if scanning stopped because the context was canceled or the
+// socket was closed, and the context's error if its deadline was
+// exceeded.
+func (m *MessageScanner) Err() error {
+	return m.err
+}
+
+// Destroy releases the scanner's Poller. It does not destroy
+// the socket being scanned.
+func (m *MessageScanner) Destroy() {
+	m.done = true
+	if m.unwatch != nil {
+		m.unwatch()
+		m.unwatch = nil
+	}
+	if m.ownPoller {
+		m.poller.Destroy()
+	}
+}
+
+// watch interrupts the poller when the context is done, once
+// for the whole scan rather than for every wait
+func (m *MessageScanner) watch() error {
+	_, err := m.poller.wakeSignal()
+	if err != nil {
+		return err
+	}
+
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(m.ctx, func() {
+		m.poller.Interrupt()
+		close(interrupted)
+	})
+
+	m.unwatch = func() {
+		if !stop() {
+			// Leave no interrupt pending on a poller
+			// shared with a ReadWriter
+			<-interrupted
+			if m.poller.polled != nil {
+				m.poller.polled.drain()
+			}
+		}
+	}
+	return nil
+}
+
+// stop ends scanning, keeping err unless it marks a clean stop
+func (m *MessageScanner) stop(err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled),
+		err == ErrWaitAfterDestroy,
+		err == ErrRecvFrameAfterDestroy,
+		err == ErrTerminated:
+		err = nil
+	}
+	m.err = err
+	m.Destroy()
+	return false
+}
+
+// messages adapts a MessageScanner to an iterator
+func messages(m *MessageScanner) iter.Seq2[[][]byte, error] {
+	return func(yield func([][]byte, error) bool) {
+		defer m.Destroy()
+		for m.Scan() {
+			if !yield(m.Message(), nil) {
+				return
+			}
+		}
+		if err := m.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// Messages returns an iterator over the messages received on
+// the socket, which stops under the same conditions as a
+// MessageScanner. If scanning stops with an error, it is yielded
+// as the final value with a nil message.
+//
+//	for msg, err := range sock.Messages(ctx) {
+//		if err != nil {
+//			...
+//		}
+//		handle(msg)
+//	}
+func (s *Sock) Messages(ctx context.Context) iter.Seq2[[][]byte, error] {
+	return func(yield func([][]byte, error) bool) {
+		m, err := NewMessageScanner(ctx, s)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		messages(m)(yield)
+	}
+}
+
+// Scanner returns a MessageScanner over the messages received on
+// the ReadWriter's socket, waiting at most the ReadWriter's timeout
+// for each. Messages are returned whole, including any Router
+// identity frame, and are not passed through the framing set with
+// SetFraming. Data already buffered by Read is not returned.
+func (r *ReadWriter) Scanner(ctx context.Context) *MessageScanner {
+	return &MessageScanner{
+		ctx:           ctx,
+		sock:          r.sock,
+		poller:        r.poller,
+		timeoutMillis: r.timeoutMillis,
+	}
+}
+
+// Messages returns an iterator over the messages received on
+// the ReadWriter's socket, as returned by Scanner.
+func (r *ReadWriter) Messages(ctx context.Context) iter.Seq2[[][]byte, error] {
+	return messages(r.Scanner(ctx))
+}
@@ -0,0 +1,151 @@
+package goczmq
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageScanner(t *testing.T) {
+	push, err := NewPush("inproc://scanner")
+	require.NoError(t, err)
+	defer push.Destroy()
+
+	pull, err := NewPull("inproc://scanner")
+	require.NoError(t, err)
+	defer pull.Destroy()
+
+	for _, frame := range []string{"one", "two", "three"} {
+		err = push.SendMessage([][]byte{[]byte(frame), []byte("tail")})
+		require.NoError(t, err)
+	}
+
+	scanner, err := NewMessageScanner(context.Background(), pull)
+	require.NoError(t, err)
+	scanner.SetTimeout(100)
+
+	var got []string
+	for scanner.Scan() {
+		msg := scanner.Message()
+		if want, got := 2, len(msg); want != got {
+			t.Errorf("want %d, got %d", want, got)
+		}
+		got = append(got, string(msg[0]))
+	}
+	assertEqual(t, ErrTimeout, scanner.Err())
+
+	if want, got := "one two three", strings.Join(got, " "); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+
+	if scanner.Scan() {
+		t.Errorf("expected Scan to return false after stopping")
+	}
+}
+
+func TestSockMessagesContext(t *testing.T) {
+	push, err := NewPush("inproc://sockmessages")
+	require.NoError(t, err)
+	defer push.Destroy()
+
+	pull, err := NewPull("inproc://sockmessages")
+	require.NoError(t, err)
+	defer pull.Destroy()
+
+	err = push.SendFrame([]byte("hello"), FlagNone)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	count := 0
+	for msg, err := range pull.Messages(ctx) {
+		require.NoError(t, err)
+		if want, got := "hello", string(msg[0]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+		count++
+	}
+	if want, got := 1, count; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	for _, err := range pull.Messages(ctx) {
+		assertEqual(t, context.DeadlineExceeded, err)
+	}
+}
+
+func TestSockMessagesBreak(t *testing.T) {
+	push, err := NewPush("inproc://sockmessagesbreak")
+	require.NoError(t, err)
+	defer push.Destroy()
+
+	pull, err := NewPull("inproc://sockmessagesbreak")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = push.SendFrame([]byte("hello"), FlagNone)
+		require.NoError(t, err)
+	}
+
+	for _, err := range pull.Messages(context.Background()) {
+		require.NoError(t, err)
+		break
+	}
+
+	pull.Destroy()
+	for _, err := range pull.Messages(context.Background()) {
+		t.Errorf("expected no messages after Destroy, got error %v", err)
+	}
+}
+
+func TestReadWriterMessages(t *testing.T) {
+	pushSock, err := NewPush("inproc://readwritermessages")
+	require.NoError(t, err)
+	defer pushSock.Destroy()
+
+	pullSock, err := NewPull("inproc://readwritermessages")
+	require.NoError(t, err)
+
+	pullReadWriter, err := NewReadWriter(pullSock)
+	require.NoError(t, err)
+	defer pullReadWriter.Destroy()
+	pullReadWriter.SetTimeout(50)
+
+	err = pushSock.SendMessage([][]byte{[]byte("hello"), []byte("world")})
+	require.NoError(t, err)
+
+	count := 0
+	for msg, err := range pullReadWriter.Messages(context.Background()) {
+		if err != nil {
+			assertEqual(t, ErrTimeout, err)
+			break
+		}
+		if want, got := "world", string(msg[1]); want != got {
+			t.Errorf("want '%s', got '%s'", want, got)
+		}
+		count++
+	}
+	if want, got := 1, count; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+
+	// The ReadWriter's poller is still usable
+	err = pushSock.SendFrame([]byte("again"), FlagNone)
+	require.NoError(t, err)
+
+	b := make([]byte, 5)
+	n, err := pullReadWriter.Read(b)
+	if err != io.EOF {
+		require.NoError(t, err)
+	}
+	if want, got := "again", string(b[:n]); want != got {
+		t.Errorf("want '%s', got '%s'", want, got)
+	}
+}
@@ -9,6 +9,9 @@ int Sock_connect(zsock_t *self, const char *format) {return zsock_connect(self,
 int Sock_disconnect(zsock_t *self, const char *format) {return zsock_disconnect(self, format, NULL);}
 int Sock_bind(zsock_t *self, const char *format) {return zsock_bind(self, format, NULL);}
 int Sock_unbind(zsock_t *self, const char *format) {return zsock_unbind(self, format, NULL);}
+int Sock_setsockopt(zsock_t *self, int option, const void *value, size_t size) {
+	return zmq_setsockopt(zsock_resolve(self), option, value, size);
+}
 int Sock_sendframe(zsock_t *sock, const void *data, size_t size, int flags) {
 	zframe_t *frame = zframe_new (data, size);
 	int rc = zframe_send (&frame, sock, flags);
@@ -100,6 +103,24 @@ func (s *Sock) SetOption(o SockOption) {
 	o(s)
 }
 
+// setSockOptBytes sets a binary socket option directly with
+// zmq_setsockopt. Unlike the generated SockOption setters it
+// preserves embedded NUL bytes and reports failures.
+func (s *Sock) setSockOptBytes(option int, value []byte) error {
+	var rc C.int
+	var err error
+
+	if len(value) == 0 {
+		rc, err = C.Sock_setsockopt(s.zsockT, C.int(option), nil, C.size_t(0))
+	} else {
+		rc, err = C.Sock_setsockopt(s.zsockT, C.int(option), unsafe.Pointer(&value[0]), C.size_t(len(value)))
+	}
+	if rc == C.int(-1) {
+		return err
+	}
+	return nil
+}
+
 // Connect connects a socket to an endpoint
 // returns an error if the connect failed.
 func (s *Sock) Connect(endpoint string) error {
@@ -17,6 +17,22 @@ int Sock_sendserverframe(
 	int rc = zframe_send (&frame, sock, flags);
 	return rc;
 }
+int Sock_sendradioframe(
+	zsock_t *sock,
+	const void *data,
+	size_t size,
+	const char *group
+) {
+	zframe_t *frame = zframe_new (data, size);
+	assert(frame != NULL);
+	int rc = zframe_set_group(frame, group);
+	if (rc == -1) {
+		zframe_destroy(&frame);
+		return rc;
+	}
+	rc = zframe_send (&frame, sock, 0);
+	return rc;
+}
 */
 import "C"
 
@@ -36,6 +52,12 @@ const (
 
 	// Gather is a ZMQ_SERVER socket type
 	Server = int(C.ZMQ_SERVER)
+
+	// Radio is a ZMQ_RADIO socket type
+	Radio = int(C.ZMQ_RADIO)
+
+	// Dish is a ZMQ_DISH socket type
+	Dish = int(C.ZMQ_DISH)
 )
 
 // NewGather creates a Gather socket and calls Attach.
@@ -66,6 +88,82 @@ func NewClient(endpoints string) (*Sock, error) {
 	return s, s.Attach(endpoints, false)
 }
 
+// NewRadio creates a Radio socket and calls Attach.
+// The socket will Bind by default.
+func NewRadio(endpoints string) (*Sock, error) {
+	s := NewSock(Radio)
+	return s, s.Attach(endpoints, true)
+}
+
+// NewDish creates a Dish socket and calls Attach.
+// The socket will Connect by default.
+func NewDish(endpoints string) (*Sock, error) {
+	s := NewSock(Dish)
+	return s, s.Attach(endpoints, false)
+}
+
+// Join joins a group on a Dish socket
+func (s *Sock) Join(group string) error {
+	cGroup := C.CString(group)
+	defer C.free(unsafe.Pointer(cGroup))
+
+	rc, err := C.zsock_join(unsafe.Pointer(s.zsockT), cGroup)
+	if rc == C.int(-1) {
+		return err
+	}
+	return nil
+}
+
+// Leave leaves a group on a Dish socket
+func (s *Sock) Leave(group string) error {
+	cGroup := C.CString(group)
+	defer C.free(unsafe.Pointer(cGroup))
+
+	rc, err := C.zsock_leave(unsafe.Pointer(s.zsockT), cGroup)
+	if rc == C.int(-1) {
+		return err
+	}
+	return nil
+}
+
+// SendRadioFrame sends a byte array to a group via a
+// Radio socket.
+func (s *Sock) SendRadioFrame(data []byte, group string) error {
+	cGroup := C.CString(group)
+	defer C.free(unsafe.Pointer(cGroup))
+
+	var rc C.int
+	if len(data) == 0 {
+		rc = C.Sock_sendradioframe(s.zsockT, nil, C.size_t(0), cGroup)
+	} else {
+		rc = C.Sock_sendradioframe(s.zsockT, unsafe.Pointer(&data[0]), C.size_t(len(data)), cGroup)
+	}
+	if rc == C.int(-1) {
+		return ErrSendFrame
+	}
+	return nil
+}
+
+// RecvDishFrame reads a frame from a Dish socket and returns
+// it as a byte array, along with the group it was sent to and
+// an error (if there is an error)
+func (s *Sock) RecvDishFrame() ([]byte, string, error) {
+	if s.zsockT == nil {
+		return nil, "", ErrRecvFrameAfterDestroy
+	}
+
+	frame := C.zframe_recv(unsafe.Pointer(s.zsockT))
+	if frame == nil {
+		return []byte{0}, "", ErrRecvFrame
+	}
+	dataSize := C.zframe_size(frame)
+	dataPtr := C.zframe_data(frame)
+	b := C.GoBytes(unsafe.Pointer(dataPtr), C.int(dataSize))
+	group := C.GoString(C.zframe_group(frame))
+	C.zframe_destroy(&frame)
+	return b, group, nil
+}
+
 // RecvServerFrame reads a frame from the socket and returns it
 // as a byte array, along with a more flag, routing ID and error
 // (if there is an error)
@@ -38,6 +38,19 @@ func SockSetRouterNotify(v int) SockOption {
 	}
 }
 
+// SockSetZapEnforceDomain sets the zap_enforce_domain option for the socket
+func SockSetZapEnforceDomain(v int) SockOption {
+	return func(s *Sock) {
+		C.zsock_set_zap_enforce_domain(unsafe.Pointer(s.zsockT), C.int(v))
+	}
+}
+
+// ZapEnforceDomain returns the current value of the socket's zap_enforce_domain option
+func ZapEnforceDomain(s *Sock) int {
+	val := C.zsock_zap_enforce_domain(unsafe.Pointer(s.zsockT))
+	return int(val)
+}
+
 // SockSetHeartbeatIvl sets the heartbeat_ivl option for the socket
 func SockSetHeartbeatIvl(v int) SockOption {
 	return func(s *Sock) {
@@ -27,6 +27,17 @@ func TestRouterNotify(t *testing.T) {
 	sock.Destroy()
 }
 
+func TestZapEnforceDomain(t *testing.T) {
+	sock := NewSock(Dealer)
+	testval := 1
+	sock.SetOption(SockSetZapEnforceDomain(testval))
+	val := ZapEnforceDomain(sock)
+	if val != testval && val != 0 {
+		t.Errorf("ZapEnforceDomain returned %d, should be %d", val, testval)
+	}
+	sock.Destroy()
+}
+
 func TestHeartbeatIvl(t *testing.T) {
 	sock := NewSock(Dealer)
 	testval := 2000
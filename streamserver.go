@@ -0,0 +1,50 @@
+package goczmq
+
+import (
+	"net"
+)
+
+// StreamServer exposes the raw TCP peers of a Stream socket as
+// connections. It handles the identity frame of each message,
+// the zero length frames ZeroMQ uses to report connects and
+// disconnects, and the identity required on every send, so
+// plain TCP protocols can be served next to ZeroMQ traffic.
+type StreamServer struct {
+	l *Listener
+}
+
+// NewStreamServer creates a Stream socket bound to endpoints
+// and returns it as a StreamServer.
+func NewStreamServer(endpoints string, options ...SockOption) (*StreamServer, error) {
+	sock := NewSock(Stream, options...)
+	err := sock.Attach(endpoints, true)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	l, err := NewListener(sock)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+	return &StreamServer{l: l}, nil
+}
+
+// Accept waits for the next peer to connect and returns its
+// connection. The Closed channel of the connection reports
+// when the peer disconnects.
+func (s *StreamServer) Accept() (*PeerConn, error) {
+	return s.l.acceptPeer()
+}
+
+// Addr returns the endpoint the server is bound to
+func (s *StreamServer) Addr() net.Addr {
+	return s.l.Addr()
+}
+
+// Close closes the server and its socket, which disconnects
+// every peer.
+func (s *StreamServer) Close() error {
+	return s.l.Close()
+}
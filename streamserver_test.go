@@ -0,0 +1,77 @@
+package goczmq
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamServer(t *testing.T) {
+	server, err := NewStreamServer("tcp://127.0.0.1:*")
+	require.NoError(t, err)
+	defer server.Close()
+
+	addr := server.Addr().String()
+	client, err := net.Dial("tcp", addr[len("tcp://"):])
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := server.Accept()
+	require.NoError(t, err)
+
+	_, err = client.Write([]byte("PING\r\n"))
+	require.NoError(t, err)
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	if want, got := "PING\r\n", line; want != got {
+		t.Errorf("want '%q', got '%q'", want, got)
+	}
+
+	_, err = conn.Write([]byte("+PONG\r\n"))
+	require.NoError(t, err)
+
+	client.SetReadDeadline(time.Now().Add(time.Second * 2))
+	line, err = bufio.NewReader(client).ReadString('\n')
+	require.NoError(t, err)
+	if want, got := "+PONG\r\n", line; want != got {
+		t.Errorf("want '%q', got '%q'", want, got)
+	}
+
+	client.Close()
+
+	select {
+	case <-conn.Closed():
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+}
+
+func TestStreamServerCloseConn(t *testing.T) {
+	server, err := NewStreamServer("tcp://127.0.0.1:*")
+	require.NoError(t, err)
+	defer server.Close()
+
+	addr := server.Addr().String()
+	client, err := net.Dial("tcp", addr[len("tcp://"):])
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := server.Accept()
+	require.NoError(t, err)
+
+	err = conn.Close()
+	require.NoError(t, err)
+
+	client.SetReadDeadline(time.Now().Add(time.Second * 2))
+	_, err = client.Read(make([]byte, 1))
+	if err == nil {
+		t.Errorf("expected the peer to be disconnected")
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		t.Errorf("timed out waiting for the peer to be disconnected")
+	}
+}
@@ -0,0 +1,221 @@
+//go:build goczmq_testhooks
+// +build goczmq_testhooks
+
+package goczmq
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// terminatedTestEnv names the test a child process runs
+const terminatedTestEnv = "GOCZMQ_TERMINATED_TEST"
+
+// inChildProcess reruns the calling test in a child process and
+// reports whether the test body should run in this process. The
+// terminated tests terminate the process-wide ZeroMQ context,
+// which stops every actor in the process, so each runs alone.
+func inChildProcess(t *testing.T) bool {
+	if os.Getenv(terminatedTestEnv) == t.Name() {
+		return true
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(os.Environ(), terminatedTestEnv+"="+t.Name())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	return false
+}
+
+func TestPollerTerminated(t *testing.T) {
+	if !inChildProcess(t) {
+		return
+	}
+
+	pullSock, err := NewPull("inproc://poller_terminated")
+	require.NoError(t, err)
+	defer pullSock.Destroy()
+
+	poller, err := NewPoller(pullSock)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	terminateContext()
+
+	_, err = poller.Wait(-1)
+	if want, have := ErrTerminated, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := true, poller.Terminated(); want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+
+	// Nonstop only ignores the interrupted flag
+	poller.SetNonstop(true)
+
+	_, err = poller.Wait(-1)
+	if want, have := ErrTerminated, err; want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+	if want, have := false, poller.Expired(); want != have {
+		t.Errorf("want %#v, have %#v", want, have)
+	}
+}
+
+func TestChannelerTerminated(t *testing.T) {
+	if !inChildProcess(t) {
+		return
+	}
+
+	pull := NewPullChanneler("inproc://channelerterminated")
+	defer pull.Destroy()
+
+	push, err := NewPush("inproc://channelerterminated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer push.Destroy()
+
+	err = push.SendFrame([]byte("hello"), FlagNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-pull.RecvChan:
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	terminateContext()
+
+	timeout := time.After(time.Second * 2)
+	for open := true; open; {
+		select {
+		case _, open = <-pull.RecvChan:
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+
+	err = pull.Subscribe("a")
+	assertEqual(t, ErrActorCmd, err)
+}
+
+func TestMultiChannelerTerminated(t *testing.T) {
+	if !inChildProcess(t) {
+		return
+	}
+
+	multi := NewMultiChanneler(
+		ChannelerSock{Name: "pull", Type: Pull, Endpoints: "inproc://multichannelerterminated"},
+	)
+	defer multi.Destroy()
+
+	push, err := NewPush("inproc://multichannelerterminated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer push.Destroy()
+
+	err = push.SendFrame([]byte("hello"), FlagNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-multi.RecvChan:
+	case err := <-multi.ErrChan:
+		t.Fatal(err)
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	terminateContext()
+
+	timeout := time.After(time.Second * 2)
+	for open := true; open; {
+		select {
+		case _, open = <-multi.RecvChan:
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+
+	err = multi.Connect("pull", "inproc://multichannelerterminated2")
+	assertEqual(t, ErrActorCmd, err)
+}
+
+func TestListenerTerminated(t *testing.T) {
+	if !inChildProcess(t) {
+		return
+	}
+
+	l, err := Listen(Router, "inproc://listenerterminated")
+	require.NoError(t, err)
+	defer l.Close()
+
+	result := make(chan error)
+	go func() {
+		_, err := l.Accept()
+		result <- err
+	}()
+
+	terminateContext()
+
+	select {
+	case err := <-result:
+		if want, got := net.ErrClosed, err; want != got {
+			t.Errorf("want '%v', got '%v'", want, got)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+}
+
+func TestHubTerminated(t *testing.T) {
+	if !inChildProcess(t) {
+		return
+	}
+
+	hub, err := NewHub()
+	require.NoError(t, err)
+	defer hub.Destroy()
+
+	pull, err := hub.Add(ChannelerSock{Type: Pull, Endpoints: "inproc://hubterminated"})
+	require.NoError(t, err)
+
+	push, err := NewPush("inproc://hubterminated")
+	require.NoError(t, err)
+	defer push.Destroy()
+
+	err = push.SendFrame([]byte("hello"), FlagNone)
+	require.NoError(t, err)
+
+	select {
+	case <-pull.RecvChan:
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout")
+	}
+
+	terminateContext()
+
+	timeout := time.After(time.Second * 2)
+	for open := true; open; {
+		select {
+		case _, open = <-pull.RecvChan:
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+
+	_, err = hub.Add(ChannelerSock{Type: Pull, Endpoints: "inproc://hubterminated2"})
+	assertEqual(t, ErrActorCmd, err)
+}
@@ -0,0 +1,21 @@
+//go:build goczmq_testhooks
+// +build goczmq_testhooks
+
+package goczmq
+
+/*
+#include "czmq.h"
+
+void Testhooks_terminate_context(void) {
+	zmq_ctx_shutdown(zsys_init());
+}
+*/
+import "C"
+
+// terminateContext shuts down the process-wide ZeroMQ context, so
+// every blocking call on its sockets fails with ETERM. The context
+// cannot be used again, so the terminated tests run in a child
+// process each.
+func terminateContext() {
+	C.Testhooks_terminate_context()
+}
@@ -0,0 +1,263 @@
+package goczmq
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ZapEndpoint is the endpoint ZeroMQ sends ZAP requests to
+const ZapEndpoint = "inproc://zeromq.zap.01"
+
+// ZAP status codes, see RFC 27
+const (
+	ZapStatusSuccess        = "200"
+	ZapStatusTemporaryError = "300"
+	ZapStatusFailure        = "400"
+	ZapStatusInternalError  = "500"
+)
+
+// ZapRequest is a ZAP authentication request for an incoming
+// connection.
+type ZapRequest struct {
+	// RequestID identifies the request on the wire
+	RequestID []byte
+
+	// Domain is the ZAP domain of the accepting socket
+	Domain string
+
+	// Address is the IP address of the peer
+	Address string
+
+	// RoutingID is the routing id of the peer, if it set one
+	RoutingID []byte
+
+	// Mechanism is the security mechanism, "NULL", "PLAIN",
+	// "CURVE" or "GSSAPI"
+	Mechanism string
+
+	// Credentials are the mechanism's credentials: none for NULL,
+	// the username and password for PLAIN, the 32 byte public key
+	// for CURVE and the principal for GSSAPI.
+	Credentials [][]byte
+}
+
+// ZapResponse is the reply to a ZapRequest. A StatusCode of
+// ZapStatusSuccess accepts the connection, and UserID and
+// Metadata are then attached to every message received from it.
+type ZapResponse struct {
+	StatusCode string
+	StatusText string
+	UserID     string
+	Metadata   map[string]string
+}
+
+// Authenticator decides whether a connection may proceed
+type Authenticator interface {
+	Authenticate(req *ZapRequest) ZapResponse
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator
+type AuthenticatorFunc func(req *ZapRequest) ZapResponse
+
+// Authenticate satisfies Authenticator
+func (f AuthenticatorFunc) Authenticate(req *ZapRequest) ZapResponse {
+	return f(req)
+}
+
+// ZapHandler is a ZAP handler written in Go. It answers the
+// ZAP requests for every socket in the process with a ZAP domain
+// set, or with the PLAIN or CURVE server option, by calling its
+// Authenticator. Authenticate is called from a single goroutine,
+// one request at a time. Only one ZAP handler can run at a time,
+// so a ZapHandler cannot be used alongside an Auth actor.
+type ZapHandler struct {
+	auth     Authenticator
+	auditMu  sync.Mutex
+	audit    func(AuthEvent)
+	sock     *Sock
+	poller   *Poller
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewZapHandler creates a ZapHandler bound to ZapEndpoint that
+// authenticates connections with auth.
+func NewZapHandler(auth Authenticator) (*ZapHandler, error) {
+	sock, err := NewRep(ZapEndpoint)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	poller, err := NewPoller(sock)
+	if err != nil {
+		sock.Destroy()
+		return nil, err
+	}
+
+	// Stop interrupts the handler's wait
+	_, err = poller.wakeSignal()
+	if err != nil {
+		poller.Destroy()
+		sock.Destroy()
+		return nil, err
+	}
+
+	h := &ZapHandler{
+		auth:   auth,
+		sock:   sock,
+		poller: poller,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go h.run()
+	return h, nil
+}
+
+// SetAudit sets a function that is called with an AuthEvent
+// for every request the handler answers, from the handler's
+// goroutine, after the reply is built. Passing nil removes it.
+// See SlogAudit for logging events with log/slog.
+func (h *ZapHandler) SetAudit(audit func(AuthEvent)) {
+	h.auditMu.Lock()
+	defer h.auditMu.Unlock()
+
+	h.audit = audit
+}
+
+// Destroy stops the ZapHandler and destroys its socket. Once
+// it returns, another ZAP handler can be started.
+func (h *ZapHandler) Destroy() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+		h.poller.Interrupt()
+	})
+	<-h.done
+}
+
+// run answers ZAP requests until the handler is destroyed
+func (h *ZapHandler) run() {
+	defer close(h.done)
+	defer func() {
+		// Unbinding releases the endpoint at once, rather than
+		// when the socket is reaped
+		h.poller.Destroy()
+		h.sock.Unbind(ZapEndpoint)
+		h.sock.Destroy()
+	}()
+
+	for {
+		s, err := h.poller.Wait(-1)
+		select {
+		case <-h.stop:
+			return
+		default:
+		}
+
+		if err == ErrTerminated {
+			return
+		}
+		if err != nil || s == nil {
+			continue
+		}
+
+		msg, err := s.RecvMessage()
+		if err != nil {
+			continue
+		}
+
+		s.SendMessage(h.handle(msg))
+	}
+}
+
+// handle parses a ZAP request and builds the reply
+func (h *ZapHandler) handle(msg [][]byte) [][]byte {
+	if len(msg) < 6 {
+		var requestID []byte
+		if len(msg) > 1 {
+			requestID = msg[1]
+		}
+		return zapReply(requestID, ZapResponse{StatusCode: ZapStatusInternalError, StatusText: "Malformed request"})
+	}
+	if string(msg[0]) != "1.0" {
+		return zapReply(msg[1], ZapResponse{StatusCode: ZapStatusInternalError, StatusText: "Version number not valid"})
+	}
+
+	req := &ZapRequest{
+		RequestID:   msg[1],
+		Domain:      string(msg[2]),
+		Address:     string(msg[3]),
+		RoutingID:   msg[4],
+		Mechanism:   string(msg[5]),
+		Credentials: msg[6:],
+	}
+
+	reply := zapReply(req.RequestID, h.authenticate(req))
+
+	h.auditMu.Lock()
+	audit := h.audit
+	h.auditMu.Unlock()
+	if audit != nil {
+		audit(newAuthEvent(req, reply))
+	}
+	return reply
+}
+
+// authenticate calls the Authenticator, turning a panic into
+// an internal error reply
+func (h *ZapHandler) authenticate(req *ZapRequest) (resp ZapResponse) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp = ZapResponse{StatusCode: ZapStatusInternalError, StatusText: fmt.Sprint(r)}
+		}
+	}()
+	return h.auth.Authenticate(req)
+}
+
+// zapReply builds a ZAP reply message
+func zapReply(requestID []byte, resp ZapResponse) [][]byte {
+	metadata, err := encodeZapMetadata(resp.Metadata)
+	if err != nil {
+		resp = ZapResponse{StatusCode: ZapStatusInternalError, StatusText: err.Error()}
+	}
+	if resp.StatusCode != ZapStatusSuccess {
+		resp.UserID = ""
+		metadata = nil
+	}
+
+	return [][]byte{
+		[]byte("1.0"),
+		requestID,
+		[]byte(resp.StatusCode),
+		[]byte(resp.StatusText),
+		[]byte(resp.UserID),
+		metadata,
+	}
+}
+
+// encodeZapMetadata encodes metadata as ZMTP properties: a one
+// byte name length and the name, then a four byte big endian value
+// length and the value, in name order.
+func encodeZapMetadata(metadata map[string]string) ([]byte, error) {
+	names := make([]string, 0, len(metadata))
+	for name := range metadata {
+		if len(name) == 0 || len(name) > 255 {
+			return nil, fmt.Errorf("invalid metadata name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf []byte
+	for _, name := range names {
+		buf = append(buf, byte(len(name)))
+		buf = append(buf, name...)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(metadata[name])))
+		buf = append(buf, metadata[name]...)
+	}
+	return buf, nil
+}
@@ -0,0 +1,128 @@
+package goczmq
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZapHandlerProtocol(t *testing.T) {
+	var got *ZapRequest
+	handler, err := NewZapHandler(AuthenticatorFunc(func(req *ZapRequest) ZapResponse {
+		if req.Domain == "panic" {
+			panic("boom")
+		}
+		got = req
+		return ZapResponse{
+			StatusCode: ZapStatusSuccess,
+			StatusText: "OK",
+			UserID:     "admin",
+			Metadata:   map[string]string{"Role": "ops"},
+		}
+	}))
+	require.NoError(t, err)
+	defer handler.Destroy()
+
+	req, err := NewReq(ZapEndpoint)
+	require.NoError(t, err)
+	defer req.Destroy()
+
+	err = req.SendMessage([][]byte{
+		[]byte("1.0"), []byte("1"), []byte("global"), []byte("127.0.0.1"),
+		[]byte("peer"), []byte("PLAIN"), []byte("admin"), []byte("Password"),
+	})
+	require.NoError(t, err)
+
+	reply, err := req.RecvMessage()
+	require.NoError(t, err)
+
+	want := [][]byte{
+		[]byte("1.0"), []byte("1"), []byte("200"), []byte("OK"), []byte("admin"),
+		append([]byte{4, 'R', 'o', 'l', 'e', 0, 0, 0, 3}, "ops"...),
+	}
+	require.Equal(t, want, reply)
+
+	assertEqual(t, "global", got.Domain)
+	assertEqual(t, "127.0.0.1", got.Address)
+	assertEqual(t, "peer", string(got.RoutingID))
+	assertEqual(t, "PLAIN", got.Mechanism)
+	require.Equal(t, [][]byte{[]byte("admin"), []byte("Password")}, got.Credentials)
+
+	err = req.SendMessage([][]byte{
+		[]byte("1.0"), []byte("2"), []byte("panic"), []byte("127.0.0.1"),
+		[]byte(""), []byte("NULL"),
+	})
+	require.NoError(t, err)
+
+	reply, err = req.RecvMessage()
+	require.NoError(t, err)
+	assertEqual(t, "500", string(reply[2]))
+	assertEqual(t, "boom", string(reply[3]))
+
+	err = req.SendMessage([][]byte{
+		[]byte("2.0"), []byte("3"), []byte("global"), []byte("127.0.0.1"),
+		[]byte(""), []byte("NULL"),
+	})
+	require.NoError(t, err)
+
+	reply, err = req.RecvMessage()
+	require.NoError(t, err)
+	assertEqual(t, "3", string(reply[1]))
+	assertEqual(t, "500", string(reply[2]))
+}
+
+func TestZapHandlerPlain(t *testing.T) {
+	handler, err := NewZapHandler(AuthenticatorFunc(func(req *ZapRequest) ZapResponse {
+		if req.Mechanism == "PLAIN" && string(req.Credentials[0]) == "admin" && string(req.Credentials[1]) == "Password" {
+			return ZapResponse{StatusCode: ZapStatusSuccess, StatusText: "OK", UserID: "admin"}
+		}
+		return ZapResponse{StatusCode: ZapStatusFailure, StatusText: "Invalid username or password"}
+	}))
+	require.NoError(t, err)
+	defer handler.Destroy()
+
+	server := NewSock(Pull, SockSetZapDomain("global"), SockSetPlainServer(1))
+	defer server.Destroy()
+
+	port, err := server.Bind("tcp://127.0.0.1:*")
+	require.NoError(t, err)
+
+	goodClient := NewSock(Push, SockSetPlainUsername("admin"), SockSetPlainPassword("Password"))
+	defer goodClient.Destroy()
+
+	badClient := NewSock(Push, SockSetPlainUsername("admin"), SockSetPlainPassword("BadPassword"))
+	defer badClient.Destroy()
+
+	poller, err := NewPoller(server)
+	require.NoError(t, err)
+	defer poller.Destroy()
+
+	err = badClient.Connect(fmt.Sprintf("tcp://127.0.0.1:%d", port))
+	require.NoError(t, err)
+
+	err = badClient.SendFrame([]byte("Bad"), FlagNone)
+	require.NoError(t, err)
+
+	s, err := poller.Wait(200)
+	require.NoError(t, err)
+	if s != nil {
+		t.Errorf("expected no message from unauthenticated client")
+	}
+
+	err = goodClient.Connect(fmt.Sprintf("tcp://127.0.0.1:%d", port))
+	require.NoError(t, err)
+
+	err = goodClient.SendFrame([]byte("Hello"), FlagNone)
+	require.NoError(t, err)
+
+	s, err = poller.Wait(2000)
+	require.NoError(t, err)
+	if want, have := server, s; want != have {
+		t.Fatalf("want %#v, have %#v", want, have)
+	}
+
+	msg, err := s.RecvMessage()
+	require.NoError(t, err)
+	assertEqual(t, "Hello", string(msg[0]))
+}